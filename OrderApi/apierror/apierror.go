@@ -0,0 +1,183 @@
+// Package apierror answers API errors as RFC 7807 problem details. Every
+// error carries a stable machine-readable code, the field it is about and a
+// message localized from the Accept-Language header of the request.
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"assignment2.id/orderapi/logging"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/text/language"
+)
+
+const ContentType = "application/problem+json"
+
+// StatusClientClosedRequest answers a request whose client went away before
+// it was handled, as nginx does. Nobody reads the answer, but the access log
+// and the metrics tell it apart from a server error.
+const StatusClientClosedRequest = 499
+
+// Error is an error answered to the client.
+type Error struct {
+	Status int
+	Code   Code
+	// Field points at the offending input: a JSON pointer into the request
+	// body such as "/Items/0/ItemCode", or the name of a path, query or
+	// header parameter.
+	Field string
+	// Args fill in the message of Code.
+	Args []interface{}
+	// Err is the underlying error. It is logged but not shown to the client.
+	Err error
+	// Violations are the individual errors of a request found invalid in
+	// several ways at once.
+	Violations []*Error
+}
+
+func New(status int, code Code, args ...interface{}) *Error {
+	return &Error{Status: status, Code: code, Args: args}
+}
+
+// WithField returns a copy of e about field.
+func (e *Error) WithField(field string) *Error {
+	copied := *e
+	copied.Field = field
+	return &copied
+}
+
+// Wrap returns a copy of e caused by err.
+func (e *Error) Wrap(err error) *Error {
+	copied := *e
+	copied.Err = err
+	return &copied
+}
+
+// Message returns the message of e in tag, one of the supported languages.
+func (e *Error) Message(tag language.Tag) string {
+	return fmt.Sprintf(messages[e.Code].in(tag), e.Args...)
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message(language.Indonesian) + " " + e.Err.Error()
+	}
+	return e.Message(language.Indonesian)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Problem is the RFC 7807 problem details body of an error response.
+type Problem struct {
+	Type     string `json:"type" example:"about:blank"`
+	Title    string `json:"title" example:"Not Found"`
+	Status   int    `json:"status" example:"404"`
+	Detail   string `json:"detail" example:"Order tidak ditemukan."`
+	Instance string `json:"instance,omitempty" example:"/v1/orders/7"`
+	Code     Code   `json:"code" example:"order_not_found"`
+	Field    string `json:"field,omitempty" example:"orderID"`
+	// Errors lists every invalid input when there is more than one.
+	Errors []Violation `json:"errors,omitempty"`
+}
+
+// Violation is one of the errors of a Problem.
+type Violation struct {
+	Code   Code   `json:"code" example:"required"`
+	Field  string `json:"field,omitempty" example:"/CustomerName"`
+	Detail string `json:"detail" example:"/CustomerName wajib diisi."`
+}
+
+// Problem returns e as a problem about instance, in the language tag.
+func (e *Error) Problem(tag language.Tag, instance string) Problem {
+	var violations []Violation
+	for _, v := range e.Violations {
+		violations = append(violations, Violation{Code: v.Code, Field: v.Field, Detail: v.Message(tag)})
+	}
+	return Problem{
+		Type:     "about:blank",
+		Title:    statusText(e.Status),
+		Status:   e.Status,
+		Detail:   e.Message(tag),
+		Instance: instance,
+		Code:     e.Code,
+		Field:    e.Field,
+		Errors:   violations,
+	}
+}
+
+func statusText(status int) string {
+	if status == StatusClientClosedRequest {
+		return "Client Closed Request"
+	}
+	return http.StatusText(status)
+}
+
+// Abort stops the handler chain with err, for Middleware to answer.
+func Abort(ctx *gin.Context, err error) {
+	ctx.Error(err)
+	ctx.Abort()
+}
+
+// Middleware answers the last error a handler aborted with as a problem.
+// Errors that are not an *Error are translated by translate, which returns
+// nil for the errors it does not know. Those are answered as an internal
+// error and logged.
+func Middleware(translate func(err error) *Error) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Next()
+		if len(ctx.Errors) == 0 || ctx.Writer.Written() {
+			return
+		}
+		err := ctx.Errors.Last().Err
+		var apiErr *Error
+		if !errors.As(err, &apiErr) {
+			if apiErr = translate(err); apiErr == nil {
+				apiErr = New(http.StatusInternalServerError, CodeInternal).Wrap(err)
+			}
+		}
+		if apiErr.Status >= http.StatusInternalServerError {
+			logging.FromContext(ctx.Request.Context()).Error("request failed", "err", err, "method", ctx.Request.Method, "path", ctx.Request.URL.Path)
+		}
+		tag := Language(ctx.GetHeader("Accept-Language"))
+		body, err := json.Marshal(apiErr.Problem(tag, ctx.Request.URL.Path))
+		if err != nil {
+			ctx.Status(http.StatusInternalServerError)
+			return
+		}
+		ctx.Header("Content-Language", tag.String())
+		ctx.Header("Vary", "Accept-Language")
+		ctx.Data(apiErr.Status, ContentType, body)
+	}
+}
+
+// NotFound answers requests to an unknown route.
+func NotFound(ctx *gin.Context) {
+	Abort(ctx, New(http.StatusNotFound, CodeRouteNotFound))
+}
+
+// InvalidBody returns the error answered when the JSON request body cannot
+// be decoded because of err.
+func InvalidBody(err error) *Error {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return New(http.StatusBadRequest, CodeInvalidType, typeErr.Field, typeErr.Type.String()).
+			WithField("/" + strings.ReplaceAll(typeErr.Field, ".", "/")).
+			Wrap(err)
+	}
+	if name := strings.TrimPrefix(err.Error(), "json: unknown field "); name != err.Error() {
+		name = strings.Trim(name, `"`)
+		return New(http.StatusBadRequest, CodeUnknownField, name).WithField("/" + name).Wrap(err)
+	}
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return New(http.StatusBadRequest, CodeMalformedBody).Wrap(err)
+	}
+	return New(http.StatusBadRequest, CodeInvalidBody, err.Error()).Wrap(err)
+}
@@ -0,0 +1,195 @@
+package apierror
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+var errKnown = errors.New("known")
+
+func translate(err error) *Error {
+	if errors.Is(err, errKnown) {
+		return New(http.StatusConflict, CodeAlreadyExists)
+	}
+	return nil
+}
+
+func TestMiddleware(t *testing.T) {
+	tests := []struct {
+		name     string
+		language string
+		handler  gin.HandlerFunc
+		status   int
+		code     Code
+		field    string
+		detail   string
+		errors   int
+	}{
+		{
+			name: "api error",
+			handler: func(ctx *gin.Context) {
+				Abort(ctx, New(http.StatusBadRequest, CodeInvalidPathParam, "orderID").WithField("orderID"))
+			},
+			status: http.StatusBadRequest,
+			code:   CodeInvalidPathParam,
+			field:  "orderID",
+			detail: "orderID harus berupa angka.",
+		},
+		{
+			name:     "english",
+			language: "en-US,en;q=0.9",
+			handler: func(ctx *gin.Context) {
+				Abort(ctx, New(http.StatusBadRequest, CodeInvalidPathParam, "orderID").WithField("orderID"))
+			},
+			status: http.StatusBadRequest,
+			code:   CodeInvalidPathParam,
+			field:  "orderID",
+			detail: "orderID must be a number.",
+		},
+		{
+			name: "wrapped api error",
+			handler: func(ctx *gin.Context) {
+				Abort(ctx, New(http.StatusNotFound, CodeOrderNotFound).Wrap(errors.New("record not found")))
+			},
+			status: http.StatusNotFound,
+			code:   CodeOrderNotFound,
+			detail: "Order tidak ditemukan.",
+		},
+		{
+			name: "translated error",
+			handler: func(ctx *gin.Context) {
+				Abort(ctx, errKnown)
+			},
+			status: http.StatusConflict,
+			code:   CodeAlreadyExists,
+			detail: "ID sudah dipakai.",
+		},
+		{
+			name: "unknown error hides its text",
+			handler: func(ctx *gin.Context) {
+				Abort(ctx, errors.New("pq: connection refused"))
+			},
+			status: http.StatusInternalServerError,
+			code:   CodeInternal,
+			detail: "Terjadi kesalahan pada server.",
+		},
+		{
+			name: "last error wins",
+			handler: func(ctx *gin.Context) {
+				ctx.Error(errors.New("first"))
+				Abort(ctx, New(http.StatusPreconditionFailed, CodeVersionMismatch))
+			},
+			status: http.StatusPreconditionFailed,
+			code:   CodeVersionMismatch,
+			detail: "Order sudah diubah oleh permintaan lain.",
+		},
+		{
+			name: "violations",
+			handler: func(ctx *gin.Context) {
+				err := New(http.StatusUnprocessableEntity, CodeValidationFailed, 1)
+				err.Violations = append(err.Violations, New(http.StatusUnprocessableEntity, CodeRequired, "/CustomerName").WithField("/CustomerName"))
+				Abort(ctx, err)
+			},
+			status: http.StatusUnprocessableEntity,
+			code:   CodeValidationFailed,
+			detail: "Ada 1 input yang tidak valid.",
+			errors: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(tt.handler, tt.language)
+			if w.Code != tt.status {
+				t.Fatalf("got status %d, want %d", w.Code, tt.status)
+			}
+			if got := w.Header().Get("Content-Type"); got != ContentType {
+				t.Errorf("got Content-Type %q, want %q", got, ContentType)
+			}
+			var problem Problem
+			if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+				t.Fatal(err)
+			}
+			if problem.Status != tt.status || problem.Code != tt.code || problem.Field != tt.field || problem.Detail != tt.detail {
+				t.Errorf("got problem %+v, want status %d, code %q, field %q, detail %q", problem, tt.status, tt.code, tt.field, tt.detail)
+			}
+			if problem.Instance != "/test" {
+				t.Errorf("got instance %q, want /test", problem.Instance)
+			}
+			if len(problem.Errors) != tt.errors {
+				t.Errorf("got %d violations, want %d", len(problem.Errors), tt.errors)
+			}
+		})
+	}
+}
+
+func TestMiddlewareLeavesResponses(t *testing.T) {
+	w := serve(func(ctx *gin.Context) {
+		ctx.String(http.StatusOK, "ok")
+	}, "")
+	if w.Code != http.StatusOK || w.Body.String() != "ok" {
+		t.Errorf("got %d %q, want the response of the handler", w.Code, w.Body)
+	}
+	w = serve(func(ctx *gin.Context) {
+		ctx.String(http.StatusAccepted, "written")
+		ctx.Error(errors.New("after the response"))
+	}, "")
+	if w.Code != http.StatusAccepted || w.Body.String() != "written" {
+		t.Errorf("got %d %q, want the response written before the error", w.Code, w.Body)
+	}
+}
+
+func TestMiddlewareLogsServerErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		title  string
+		logged bool
+	}{
+		{"client closed the request", New(StatusClientClosedRequest, CodeRequestCanceled), "Client Closed Request", false},
+		{"client error", New(http.StatusNotFound, CodeOrderNotFound), "Not Found", false},
+		{"timeout", New(http.StatusGatewayTimeout, CodeDatabaseTimeout), "Gateway Timeout", true},
+		{"unknown error", errors.New("pq: connection refused"), "Internal Server Error", true},
+	}
+	defer slog.SetDefault(slog.Default())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+			w := serve(func(ctx *gin.Context) { Abort(ctx, tt.err) }, "")
+			var problem Problem
+			if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+				t.Fatal(err)
+			}
+			if problem.Title != tt.title {
+				t.Errorf("got title %q, want %q", problem.Title, tt.title)
+			}
+			if logged := strings.Contains(logs.String(), "request failed"); logged != tt.logged {
+				t.Errorf("got logged %v, want %v: %s", logged, tt.logged, logs.String())
+			}
+		})
+	}
+}
+
+// serve runs handler behind Middleware for a GET /test with the
+// Accept-Language header language.
+func serve(handler gin.HandlerFunc, language string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Middleware(translate))
+	router.GET("/test", handler)
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	if language != "" {
+		req.Header.Set("Accept-Language", language)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
@@ -0,0 +1,152 @@
+package apierror
+
+import "golang.org/x/text/language"
+
+// Code identifies an error for clients. Codes never change once published.
+type Code string
+
+const (
+	CodeInternal      Code = "internal_error"
+	CodeRouteNotFound Code = "route_not_found"
+
+	CodeMalformedBody      Code = "malformed_body"
+	CodeInvalidBody        Code = "invalid_body"
+	CodeInvalidType        Code = "invalid_type"
+	CodeUnknownField       Code = "unknown_field"
+	CodeInvalidPathParam   Code = "invalid_path_param"
+	CodeInvalidPurge       Code = "invalid_purge"
+	CodeInvalidLimit       Code = "invalid_limit"
+	CodeInvalidOffset      Code = "invalid_offset"
+	CodeCursorWithOffset   Code = "cursor_with_offset"
+	CodeInvalidCursor      Code = "invalid_cursor"
+	CodeInvalidSort        Code = "invalid_sort"
+	CodeInvalidTime        Code = "invalid_time"
+	CodeInvalidID          Code = "invalid_id"
+	CodeInvalidIDCount     Code = "invalid_id_count"
+	CodeCustomerNameEmpty  Code = "customer_name_empty"
+	CodeItemCodeEmpty      Code = "item_code_empty"
+	CodeOrderedAtNull      Code = "ordered_at_null"
+	CodeIDImmutable        Code = "id_immutable"
+	CodeItemNotInOrder     Code = "item_not_in_order"
+	CodeAlreadyExists      Code = "already_exists"
+	CodeUnsupportedMedia   Code = "unsupported_media_type"
+	CodeMalformedPatch     Code = "malformed_patch"
+	CodeInvalidPatch       Code = "invalid_patch"
+	CodePatchTestFailed    Code = "patch_test_failed"
+	CodeOrderNotFound      Code = "order_not_found"
+	CodeItemNotFound       Code = "item_not_found"
+	CodeOrderNotDeleted    Code = "order_not_deleted"
+	CodeIfMatchRequired    Code = "if_match_required"
+	CodeVersionMismatch    Code = "version_mismatch"
+	CodeIdempotencyKeyLong Code = "idempotency_key_too_long"
+	CodeIdempotencyReused  Code = "idempotency_key_reused"
+	CodeIdempotencyInUse   Code = "idempotency_key_in_use"
+	CodeUnauthenticated    Code = "unauthenticated"
+	CodeInvalidCredentials Code = "invalid_credentials"
+	CodeForbidden          Code = "forbidden"
+	CodeAPIKeyNotFound     Code = "api_key_not_found"
+	CodeTenantRequired     Code = "tenant_required"
+	CodeInvalidTenant      Code = "invalid_tenant"
+	CodeTenantForbidden    Code = "tenant_forbidden"
+	CodeOrderQuotaExceeded Code = "order_quota_exceeded"
+	CodeRateLimited        Code = "rate_limited"
+	CodeDatabaseTimeout    Code = "database_timeout"
+	CodeRequestCanceled    Code = "request_canceled"
+
+	// CodeValidationFailed holds the violations of the validation rules,
+	// each with one of the codes below.
+	CodeValidationFailed Code = "validation_failed"
+	CodeRequired         Code = "required"
+	CodeTooLong          Code = "too_long"
+	CodeTooMany          Code = "too_many"
+	CodeNotPositive      Code = "not_positive"
+	CodeInvalidFormat    Code = "invalid_format"
+	CodeTooFarAhead      Code = "too_far_ahead"
+	CodeNotAllowed       Code = "not_allowed"
+)
+
+// message is the text of a code in every supported language, a format
+// filled in with Error.Args.
+type message struct {
+	id, en string
+}
+
+func (m message) in(tag language.Tag) string {
+	if tag == language.English {
+		return m.en
+	}
+	return m.id
+}
+
+var messages = map[Code]message{
+	CodeInternal:      {"Terjadi kesalahan pada server.", "Something went wrong on the server."},
+	CodeRouteNotFound: {"Endpoint tidak ditemukan.", "No such endpoint."},
+
+	CodeMalformedBody:      {"Body bukan JSON yang valid.", "The body is not valid JSON."},
+	CodeInvalidBody:        {"Body tidak valid: %s", "Invalid body: %s"},
+	CodeInvalidType:        {"%s harus bertipe %s.", "%s must be of type %s."},
+	CodeUnknownField:       {"Field %s tidak dikenal.", "Unknown field %s."},
+	CodeInvalidPathParam:   {"%s harus berupa angka.", "%s must be a number."},
+	CodeInvalidPurge:       {"purge harus true atau false.", "purge must be true or false."},
+	CodeInvalidLimit:       {"limit harus antara 1 dan %d.", "limit must be between 1 and %d."},
+	CodeInvalidOffset:      {"offset tidak valid.", "offset must be a number of at least 0."},
+	CodeCursorWithOffset:   {"cursor dan offset tidak bisa dipakai bersamaan.", "cursor and offset cannot be used together."},
+	CodeInvalidCursor:      {"Cursor tidak valid.", "The cursor is invalid."},
+	CodeInvalidSort:        {"Kolom sort tidak dikenal.", "Unknown sort column."},
+	CodeInvalidTime:        {"%s harus berformat RFC 3339.", "%s must be an RFC 3339 time."},
+	CodeInvalidID:          {"id %q tidak valid.", "id %q is invalid."},
+	CodeInvalidIDCount:     {"jumlah ids harus antara 1 dan %d.", "ids must hold between 1 and %d IDs."},
+	CodeCustomerNameEmpty:  {"CustomerName kosong.", "CustomerName is empty."},
+	CodeItemCodeEmpty:      {"ItemCode kosong.", "ItemCode is empty."},
+	CodeOrderedAtNull:      {"OrderedAt tidak bisa dikosongkan.", "OrderedAt cannot be removed."},
+	CodeIDImmutable:        {"ID tidak bisa diubah.", "ID cannot be changed."},
+	CodeItemNotInOrder:     {"Item bukan milik order ini.", "The item does not belong to this order."},
+	CodeAlreadyExists:      {"ID sudah dipakai.", "The ID is already taken."},
+	CodeUnsupportedMedia:   {"Content-Type harus %s atau %s.", "Content-Type must be %s or %s."},
+	CodeMalformedPatch:     {"Patch tidak valid: %s", "Invalid patch: %s"},
+	CodeInvalidPatch:       {"Patch tidak bisa diterapkan: %s", "The patch cannot be applied: %s"},
+	CodePatchTestFailed:    {"Operasi test pada patch gagal.", "A test operation of the patch failed."},
+	CodeOrderNotFound:      {"Order tidak ditemukan.", "Order not found."},
+	CodeItemNotFound:       {"Item tidak ditemukan di order ini.", "Item not found in this order."},
+	CodeOrderNotDeleted:    {"Order tidak sedang terhapus.", "The order is not deleted."},
+	CodeIfMatchRequired:    {"Header If-Match wajib diisi dengan ETag order.", "The If-Match header must hold the ETag of the order."},
+	CodeVersionMismatch:    {"Order sudah diubah oleh permintaan lain.", "The order was changed by another request."},
+	CodeIdempotencyKeyLong: {"Idempotency-Key maksimal %d karakter.", "Idempotency-Key must be at most %d characters."},
+	CodeIdempotencyReused:  {"Idempotency-Key sudah dipakai untuk permintaan lain.", "The Idempotency-Key was used for another request."},
+	CodeIdempotencyInUse:   {"Idempotency-Key sedang dipakai.", "The Idempotency-Key is in use."},
+	CodeUnauthenticated:    {"Autentikasi diperlukan: kirim header %s atau Authorization: Bearer.", "Authentication required: send the %s header or Authorization: Bearer."},
+	CodeInvalidCredentials: {"API key atau token tidak valid.", "The API key or token is invalid."},
+	CodeForbidden:          {"Akses ditolak, tidak diizinkan %s.", "Access denied, %s is not allowed."},
+	CodeAPIKeyNotFound:     {"API key tidak ditemukan.", "API key not found."},
+	CodeTenantRequired:     {"Tenant tidak diketahui: kirim header %s atau pakai kredensial tenant.", "Unknown tenant: send the %s header or use the credentials of a tenant."},
+	CodeInvalidTenant:      {"Tenant %q tidak valid: maksimal 63 huruf kecil, angka dan strip.", "Tenant %q is invalid: at most 63 lowercase letters, digits and hyphens."},
+	CodeTenantForbidden:    {"Akses ditolak, kredensial ini bukan milik tenant %s.", "Access denied, these credentials do not belong to tenant %s."},
+	CodeOrderQuotaExceeded: {"Kuota tenant sudah penuh, maksimal %d order.", "The tenant is at its quota of %d orders."},
+	CodeRateLimited:        {"Terlalu banyak permintaan, coba lagi dalam %d detik.", "Too many requests, retry in %d seconds."},
+	CodeDatabaseTimeout:    {"Database tidak menjawab tepat waktu, coba lagi nanti.", "The database did not answer in time, retry later."},
+	CodeRequestCanceled:    {"Permintaan dibatalkan oleh client.", "The client canceled the request."},
+
+	CodeValidationFailed: {"Ada %d input yang tidak valid.", "%d inputs are invalid."},
+	// The rules are given the field and the limit of the rule.
+	CodeRequired:      {"%[1]s wajib diisi.", "%[1]s is required."},
+	CodeTooLong:       {"%[1]s maksimal %[2]s karakter.", "%[1]s must be at most %[2]s characters."},
+	CodeTooMany:       {"%[1]s maksimal %[2]s item.", "%[1]s must hold at most %[2]s items."},
+	CodeNotPositive:   {"%[1]s harus lebih dari 0.", "%[1]s must be greater than 0."},
+	CodeInvalidFormat: {"%[1]s hanya boleh berisi huruf, angka, titik, strip dan garis bawah, diawali huruf atau angka.", "%[1]s may only hold letters, digits, dots, dashes and underscores, starting with a letter or digit."},
+	CodeTooFarAhead:   {"%[1]s tidak boleh lebih dari %[2]s jam ke depan.", "%[1]s must not be more than %[2]s hours ahead."},
+	CodeNotAllowed:    {"%[1]s harus salah satu dari: %[2]s.", "%[1]s must be one of: %[2]s."},
+}
+
+// supported lists the message languages, the first is the default.
+var supported = language.NewMatcher([]language.Tag{language.Indonesian, language.English})
+
+// Language returns the supported language that best matches an
+// Accept-Language header.
+func Language(acceptLanguage string) language.Tag {
+	tags, _, _ := language.ParseAcceptLanguage(acceptLanguage)
+	_, index, _ := supported.Match(tags...)
+	if index == 1 {
+		return language.English
+	}
+	return language.Indonesian
+}
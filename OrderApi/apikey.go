@@ -0,0 +1,100 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"assignment2.id/orderapi/auth"
+	"assignment2.id/orderapi/database"
+	"assignment2.id/orderapi/validation"
+)
+
+const apiKeyUsage = "usage: orderapi [flags] apikey create [-roles user,admin] [-tenant acme] <name> | list | revoke <id>"
+
+// runAPIKey manages API keys from the command line, which is how the first
+// admin key is made.
+func runAPIKey(store database.OrderStore, args []string) error {
+	if len(args) == 0 {
+		return errors.New(apiKeyUsage)
+	}
+	migrator, err := migratorFor(store)
+	if err != nil {
+		return err
+	}
+	if migrator == nil {
+		return errors.New("the configured db driver keeps no API keys between runs")
+	}
+	if err := migrator.CheckCurrent(); err != nil {
+		return err
+	}
+	switch args[0] {
+	case "create":
+		fs := flag.NewFlagSet("apikey create", flag.ContinueOnError)
+		roles := fs.String("roles", auth.RoleUser, "comma separated roles of the key, of "+strings.Join(auth.Roles, ", "))
+		tenant := fs.String("tenant", "", "tenant the key is limited to, empty for every tenant")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		if fs.NArg() != 1 || fs.Arg(0) == "" {
+			return errors.New(apiKeyUsage)
+		}
+		roleList := strings.Split(*roles, ",")
+		for _, role := range roleList {
+			if !auth.ValidRole(role) {
+				return fmt.Errorf("unknown role %q, use %s", role, strings.Join(auth.Roles, ", "))
+			}
+		}
+		if *tenant != "" && !validation.TenantID(*tenant) {
+			return fmt.Errorf("invalid tenant %q, use at most 63 lowercase letters, digits and hyphens", *tenant)
+		}
+		key, record, err := auth.NewAPIKey(fs.Arg(0), roleList, *tenant)
+		if err != nil {
+			return err
+		}
+		if err := store.CreateAPIKey(&record); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Created API key %d, it is not shown again:\n", record.ID)
+		fmt.Println(key)
+		return nil
+	case "list":
+		keys, err := store.ListAPIKeys()
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tNAME\tPREFIX\tROLES\tTENANT\tCREATED AT\tREVOKED AT")
+		for _, key := range keys {
+			revokedAt := "-"
+			if key.RevokedAt != nil {
+				revokedAt = key.RevokedAt.Format("2006-01-02 15:04:05 MST")
+			}
+			tenant := key.TenantID
+			if tenant == "" {
+				tenant = "-"
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n", key.ID, key.Name, key.Prefix, key.Roles, tenant,
+				key.CreatedAt.Format("2006-01-02 15:04:05 MST"), revokedAt)
+		}
+		return w.Flush()
+	case "revoke":
+		if len(args) != 2 {
+			return errors.New(apiKeyUsage)
+		}
+		id, err := strconv.ParseUint(args[1], 10, 0)
+		if err != nil {
+			return fmt.Errorf("id %q: %w", args[1], err)
+		}
+		if _, err := store.RevokeAPIKey(uint(id)); err != nil {
+			return err
+		}
+		fmt.Printf("revoked API key %d\n", id)
+		return nil
+	}
+	return errors.New(apiKeyUsage)
+}
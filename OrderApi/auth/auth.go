@@ -0,0 +1,165 @@
+// Package auth authenticates API requests with a static API key in the
+// X-API-Key header or a JWT bearer token in the Authorization header, and
+// makes the authenticated Principal available to the handlers.
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"assignment2.id/orderapi/apierror"
+	"assignment2.id/orderapi/config"
+	"assignment2.id/orderapi/database"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	APIKeyHeader = "X-API-Key"
+	// challenge is the WWW-Authenticate header of a 401.
+	challenge = `Bearer realm="orderapi"`
+	// principalKey holds the Principal in the gin context.
+	principalKey = "auth.principal"
+)
+
+// The roles a principal may have.
+const (
+	RoleUser    = "user"
+	RoleSupport = "support"
+	RoleAdmin   = "admin"
+)
+
+// Roles lists every role.
+var Roles = []string{RoleUser, RoleSupport, RoleAdmin}
+
+// How a Principal authenticated.
+const (
+	MethodAPIKey = "api_key"
+	MethodJWT    = "jwt"
+	// MethodNone is the anonymous admin of a server with auth disabled.
+	MethodNone = "none"
+)
+
+var ErrInvalidCredentials error = errors.New("Kredensial tidak valid.")
+
+// Principal is who a request is made by.
+type Principal struct {
+	// Subject identifies the principal: the name of an API key or the sub
+	// claim of a JWT.
+	Subject string
+	Roles   []string
+	Method  string
+	// Tenant is the tenant the principal is limited to, the tenant of its
+	// API key or the tenant claim of its JWT. Empty, it reaches every tenant.
+	Tenant string
+}
+
+func (p *Principal) HasRole(role string) bool {
+	for _, r := range p.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// PrincipalFrom returns the principal of the request, nil when it is not
+// authenticated.
+func PrincipalFrom(ctx *gin.Context) *Principal {
+	if p, ok := ctx.Get(principalKey); ok {
+		return p.(*Principal)
+	}
+	return nil
+}
+
+// APIKeyStore looks up API keys by hash, in the context of the request.
+type APIKeyStore interface {
+	GetAPIKeyByHash(hash string) (database.APIKey, error)
+	WithContext(ctx context.Context) database.OrderStore
+}
+
+// Authenticator checks the credentials of requests.
+type Authenticator struct {
+	Keys APIKeyStore
+	// Tokens verifies bearer tokens, nil rejects them.
+	Tokens *TokenVerifier
+}
+
+// Authenticate returns the principal of the credentials of the request. It
+// returns nil without error when the request carries none, and
+// ErrInvalidCredentials when they are wrong.
+func (a *Authenticator) Authenticate(req *http.Request) (*Principal, error) {
+	if key := req.Header.Get(APIKeyHeader); key != "" {
+		stored, err := a.Keys.WithContext(req.Context()).GetAPIKeyByHash(HashAPIKey(key))
+		if errors.Is(err, database.ErrAPIKeyNotFound) {
+			return nil, ErrInvalidCredentials
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &Principal{Subject: stored.Name, Roles: SplitRoles(stored.Roles), Method: MethodAPIKey, Tenant: stored.TenantID}, nil
+	}
+	header := req.Header.Get("Authorization")
+	if header == "" {
+		return nil, nil
+	}
+	scheme, token, _ := strings.Cut(header, " ")
+	if !strings.EqualFold(scheme, "Bearer") || a.Tokens == nil {
+		return nil, ErrInvalidCredentials
+	}
+	return a.Tokens.Verify(strings.TrimSpace(token))
+}
+
+// New returns the authenticator cfg configures, checking API keys against
+// keys.
+func New(cfg config.AuthConfig, keys APIKeyStore) (*Authenticator, error) {
+	a := &Authenticator{Keys: keys}
+	switch {
+	case cfg.JWT.JWKSFile != "":
+		tokens, err := LoadJWKS(cfg.JWT.JWKSFile, cfg.JWT.Issuer, cfg.JWT.Audience)
+		if err != nil {
+			return nil, err
+		}
+		a.Tokens = tokens
+	case cfg.JWT.HMACSecret != "":
+		a.Tokens = NewHMACVerifier([]byte(cfg.JWT.HMACSecret), cfg.JWT.Issuer, cfg.JWT.Audience)
+	}
+	if a.Tokens != nil {
+		a.Tokens.TenantClaim = cfg.JWT.TenantClaim
+	}
+	return a, nil
+}
+
+// Middleware aborts requests that do not authenticate with 401 and stores
+// the principal of the others for PrincipalFrom.
+func (a *Authenticator) Middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		principal, err := a.Authenticate(ctx.Request)
+		switch {
+		case errors.Is(err, ErrInvalidCredentials):
+			ctx.Header("WWW-Authenticate", challenge+`, error="invalid_token"`)
+			apierror.Abort(ctx, apierror.New(http.StatusUnauthorized, apierror.CodeInvalidCredentials).Wrap(err))
+			return
+		case err != nil:
+			apierror.Abort(ctx, err)
+			return
+		case principal == nil:
+			ctx.Header("WWW-Authenticate", challenge)
+			apierror.Abort(ctx, apierror.New(http.StatusUnauthorized, apierror.CodeUnauthenticated, APIKeyHeader))
+			return
+		}
+		ctx.Set(principalKey, principal)
+		ctx.Next()
+	}
+}
+
+// Anonymous lets every request in as an admin, for a server with auth
+// disabled.
+func Anonymous() gin.HandlerFunc {
+	principal := &Principal{Subject: "anonymous", Roles: Roles, Method: MethodNone}
+	return func(ctx *gin.Context) {
+		ctx.Set(principalKey, principal)
+		ctx.Next()
+	}
+}
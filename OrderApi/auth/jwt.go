@@ -0,0 +1,104 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	jose "gopkg.in/go-jose/go-jose.v2"
+	"gopkg.in/go-jose/go-jose.v2/jwt"
+)
+
+// leeway is the clock skew allowed when checking exp and nbf.
+const leeway = time.Minute
+
+// TokenVerifier checks JWT bearer tokens, signed either with an HMAC secret
+// or with one of the keys of a JWKS, identified by the kid header.
+type TokenVerifier struct {
+	// key is the HMAC secret as []byte or a *jose.JSONWebKeySet.
+	key      interface{}
+	hmac     bool
+	issuer   string
+	audience string
+	// TenantClaim names the claim holding the tenant of the principal,
+	// empty ignores it.
+	TenantClaim string
+}
+
+// claims are the claims of a token the verifier reads.
+type claims struct {
+	jwt.Claims
+	Roles []string `json:"roles"`
+}
+
+// NewHMACVerifier returns a verifier of tokens signed with HS256, HS384 or
+// HS512 and secret. issuer and audience, when not empty, must match the iss
+// and aud claims.
+func NewHMACVerifier(secret []byte, issuer, audience string) *TokenVerifier {
+	return &TokenVerifier{key: secret, hmac: true, issuer: issuer, audience: audience}
+}
+
+// LoadJWKS returns a verifier of tokens signed with the public keys of the
+// JWKS file at path.
+func LoadJWKS(path, issuer, audience string) (*TokenVerifier, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("auth: reading jwks: %w", err)
+	}
+	var keys jose.JSONWebKeySet
+	if err := json.Unmarshal(raw, &keys); err != nil {
+		return nil, fmt.Errorf("auth: %s: %w", path, err)
+	}
+	if len(keys.Keys) == 0 {
+		return nil, fmt.Errorf("auth: %s holds no keys", path)
+	}
+	for _, key := range keys.Keys {
+		if !key.IsPublic() {
+			return nil, fmt.Errorf("auth: %s: key %q is not a public key", path, key.KeyID)
+		}
+	}
+	return &TokenVerifier{key: &keys, issuer: issuer, audience: audience}, nil
+}
+
+// Verify returns the principal of token, a JWT with a sub and an exp claim,
+// the roles of the principal in a roles claim and its tenant in the
+// TenantClaim, a string. It returns
+// ErrInvalidCredentials when the token is malformed, wrongly signed,
+// expired or meant for another issuer or audience.
+func (v *TokenVerifier) Verify(token string) (*Principal, error) {
+	parsed, err := jwt.ParseSigned(token)
+	if err != nil || len(parsed.Headers) != 1 {
+		return nil, ErrInvalidCredentials
+	}
+	// A token signed with the HMAC of a public key must not pass for one
+	// signed with the private key, and the other way round.
+	if strings.HasPrefix(parsed.Headers[0].Algorithm, "HS") != v.hmac {
+		return nil, ErrInvalidCredentials
+	}
+	var c claims
+	var all map[string]interface{}
+	if err := parsed.Claims(v.key, &c, &all); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+	if c.Subject == "" || c.Expiry == nil {
+		return nil, ErrInvalidCredentials
+	}
+	expected := jwt.Expected{Issuer: v.issuer, Time: time.Now()}
+	if v.audience != "" {
+		expected.Audience = jwt.Audience{v.audience}
+	}
+	if err := c.ValidateWithLeeway(expected, leeway); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+	principal := &Principal{Subject: c.Subject, Roles: c.Roles, Method: MethodJWT}
+	if v.TenantClaim != "" && all[v.TenantClaim] != nil {
+		tenant, ok := all[v.TenantClaim].(string)
+		if !ok {
+			return nil, ErrInvalidCredentials
+		}
+		principal.Tenant = tenant
+	}
+	return principal, nil
+}
@@ -0,0 +1,785 @@
+// Package config loads the OrderApi settings. Every setting has a default
+// which is overridden, from lowest to highest precedence, by the config file
+// (YAML or TOML, chosen by extension), by ORDERAPI_* environment variables
+// and by command line flags.
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+const envPrefix = "ORDERAPI_"
+
+type Config struct {
+	ListenAddr string       `yaml:"listen_addr" toml:"listen_addr"`
+	Server     ServerConfig `yaml:"server" toml:"server"`
+	LogLevel   string       `yaml:"log_level" toml:"log_level"`
+	// LogFormat is json or text. LogRedactPII masks the customer names, and
+	// the string values of the SQL statements, in the logs.
+	LogFormat    string      `yaml:"log_format" toml:"log_format"`
+	LogRedactPII bool        `yaml:"log_redact_pii" toml:"log_redact_pii"`
+	DB           DBConfig    `yaml:"db" toml:"db"`
+	Purge        PurgeConfig `yaml:"purge" toml:"purge"`
+	// Idempotency controls the Idempotency-Key header of POST /orders.
+	Idempotency IdempotencyConfig `yaml:"idempotency" toml:"idempotency"`
+	Auth        AuthConfig        `yaml:"auth" toml:"auth"`
+	Tenancy     TenancyConfig     `yaml:"tenancy" toml:"tenancy"`
+	RateLimit   RateLimitConfig   `yaml:"rate_limit" toml:"rate_limit"`
+	Metrics     MetricsConfig     `yaml:"metrics" toml:"metrics"`
+	Tracing     TracingConfig     `yaml:"tracing" toml:"tracing"`
+	Health      HealthConfig      `yaml:"health" toml:"health"`
+	// Args are the command line arguments left after the flags.
+	Args []string `yaml:"-" toml:"-"`
+}
+
+// ServerConfig hardens the HTTP server listening on ListenAddr.
+type ServerConfig struct {
+	// ReadHeaderTimeout and ReadTimeout bound reading the headers and the
+	// whole request, WriteTimeout writing the response and IdleTimeout how
+	// long a keep-alive connection waits for the next request. 0 is no
+	// timeout.
+	ReadHeaderTimeout Duration `yaml:"read_header_timeout" toml:"read_header_timeout"`
+	ReadTimeout       Duration `yaml:"read_timeout" toml:"read_timeout"`
+	WriteTimeout      Duration `yaml:"write_timeout" toml:"write_timeout"`
+	IdleTimeout       Duration `yaml:"idle_timeout" toml:"idle_timeout"`
+	// MaxHeaderBytes is the largest request header accepted.
+	MaxHeaderBytes int `yaml:"max_header_bytes" toml:"max_header_bytes"`
+	// DrainDelay is how long the server keeps serving, on SIGTERM or
+	// SIGINT, with /readyz failing, before it stops accepting connections.
+	DrainDelay Duration `yaml:"drain_delay" toml:"drain_delay"`
+	// ShutdownTimeout is how long the server then waits for the requests in
+	// flight before cutting them off.
+	ShutdownTimeout Duration  `yaml:"shutdown_timeout" toml:"shutdown_timeout"`
+	TLS             TLSConfig `yaml:"tls" toml:"tls"`
+}
+
+// TLSConfig serves HTTPS with the certificate and key of the PEM files,
+// reloaded when they change on disk. Without them the server speaks HTTP.
+type TLSConfig struct {
+	CertFile string `yaml:"cert_file" toml:"cert_file"`
+	KeyFile  string `yaml:"key_file" toml:"key_file"`
+	// ReloadInterval is how often the files are checked for changes.
+	ReloadInterval Duration `yaml:"reload_interval" toml:"reload_interval"`
+}
+
+type DBConfig struct {
+	Driver          string   `yaml:"driver" toml:"driver"`
+	SQLitePath      string   `yaml:"sqlite_path" toml:"sqlite_path"`
+	Host            string   `yaml:"host" toml:"host"`
+	Port            int      `yaml:"port" toml:"port"`
+	User            string   `yaml:"user" toml:"user"`
+	Password        string   `yaml:"password" toml:"password"`
+	PasswordFile    string   `yaml:"password_file" toml:"password_file"`
+	Name            string   `yaml:"name" toml:"name"`
+	SSLMode         string   `yaml:"sslmode" toml:"sslmode"`
+	MaxOpenConns    int      `yaml:"max_open_conns" toml:"max_open_conns"`
+	MaxIdleConns    int      `yaml:"max_idle_conns" toml:"max_idle_conns"`
+	ConnMaxLifetime Duration `yaml:"conn_max_lifetime" toml:"conn_max_lifetime"`
+	// AutoMigrate applies pending migrations at startup instead of refusing to serve.
+	AutoMigrate bool `yaml:"auto_migrate" toml:"auto_migrate"`
+	// RowLevelSecurity sets the tenant of every postgres transaction made
+	// for a tenant, so the row level security policies hide the rows of
+	// other tenants as well.
+	RowLevelSecurity bool `yaml:"row_level_security" toml:"row_level_security"`
+	// Timeouts bound the calls to the store, cancelling their queries.
+	Timeouts DBTimeouts `yaml:"timeouts" toml:"timeouts"`
+}
+
+// DBTimeouts bound each call to the store, 0 is no timeout. A call running
+// longer is answered with 504.
+type DBTimeouts struct {
+	// Read bounds the calls only reading, Write those changing something
+	// and Purge the background purges.
+	Read  Duration `yaml:"read" toml:"read"`
+	Write Duration `yaml:"write" toml:"write"`
+	Purge Duration `yaml:"purge" toml:"purge"`
+	// Operations replace them for single calls, keyed by the OrderStore
+	// method: "ListOrders". Config file only.
+	Operations map[string]Duration `yaml:"operations" toml:"operations"`
+}
+
+// PurgeConfig controls the background job permanently deleting soft deleted orders.
+type PurgeConfig struct {
+	// Retention is how long a deleted order stays restorable, 0 disables purging.
+	Retention Duration `yaml:"retention" toml:"retention"`
+	Interval  Duration `yaml:"interval" toml:"interval"`
+}
+
+// IdempotencyConfig controls how long the response to a request carrying an
+// Idempotency-Key header is kept for replay.
+type IdempotencyConfig struct {
+	// TTL is how long a key is remembered, 0 ignores the header.
+	TTL Duration `yaml:"ttl" toml:"ttl"`
+	// Interval is how often expired keys are deleted.
+	Interval Duration `yaml:"interval" toml:"interval"`
+}
+
+// AuthConfig controls how API requests authenticate.
+type AuthConfig struct {
+	// Enabled requires every API request to carry an API key or a JWT.
+	// Disabled, every request acts as an anonymous admin.
+	Enabled bool      `yaml:"enabled" toml:"enabled"`
+	JWT     JWTConfig `yaml:"jwt" toml:"jwt"`
+}
+
+// JWTConfig selects how bearer tokens are verified: with the public keys of
+// a JWKS file or with an HMAC secret. With neither, bearer tokens are
+// rejected and only API keys authenticate.
+type JWTConfig struct {
+	JWKSFile       string `yaml:"jwks_file" toml:"jwks_file"`
+	HMACSecret     string `yaml:"hmac_secret" toml:"hmac_secret"`
+	HMACSecretFile string `yaml:"hmac_secret_file" toml:"hmac_secret_file"`
+	// Issuer and Audience, when set, must match the iss and aud claims.
+	Issuer   string `yaml:"issuer" toml:"issuer"`
+	Audience string `yaml:"audience" toml:"audience"`
+	// TenantClaim is the claim holding the tenant the token is limited to.
+	TenantClaim string `yaml:"tenant_claim" toml:"tenant_claim"`
+}
+
+// TenancyConfig splits the orders between tenants, the business units
+// sharing the server.
+type TenancyConfig struct {
+	// Enabled requires every order request to resolve to a tenant and limits
+	// it to the orders of that tenant.
+	Enabled bool `yaml:"enabled" toml:"enabled"`
+	// Sources are where the tenant of a request is looked for, in order:
+	// claim (the tenant of the API key or JWT), header and subdomain.
+	Sources []string `yaml:"sources" toml:"sources"`
+	Header  string   `yaml:"header" toml:"header"`
+	// BaseDomain is the domain the subdomain source takes the tenant from,
+	// acme.orders.example.com is acme for orders.example.com.
+	BaseDomain string `yaml:"base_domain" toml:"base_domain"`
+	// MaxOrders is how many orders a tenant may have, 0 is unlimited.
+	MaxOrders int `yaml:"max_orders" toml:"max_orders"`
+	// Rate is how many order requests per second a tenant may make, with
+	// bursts of up to Burst requests. 0 is unlimited.
+	Rate  float64 `yaml:"rate" toml:"rate"`
+	Burst int     `yaml:"burst" toml:"burst"`
+}
+
+// RateLimitConfig limits how often clients call the API.
+type RateLimitConfig struct {
+	Enabled bool `yaml:"enabled" toml:"enabled"`
+	// IP limits each client IP on every request, before authentication.
+	IP RateLimit `yaml:"ip" toml:"ip"`
+	// Read limits the GET requests of each API key or JWT subject, Write
+	// the requests changing something.
+	Read  RateLimit `yaml:"read" toml:"read"`
+	Write RateLimit `yaml:"write" toml:"write"`
+	// Routes replace Read or Write on single routes, keyed by method and
+	// path pattern without the version: "POST /orders". Config file only.
+	Routes map[string]RateLimit `yaml:"routes" toml:"routes"`
+	// TrustedProxies are the addresses or CIDRs of the proxies whose
+	// X-Forwarded-For header gives the client IP. Without, the client IP is
+	// the address of the connection.
+	TrustedProxies []string `yaml:"trusted_proxies" toml:"trusted_proxies"`
+}
+
+// MetricsConfig exposes the metrics of the API to Prometheus on Path, next
+// to the API and without authentication.
+type MetricsConfig struct {
+	Enabled bool   `yaml:"enabled" toml:"enabled"`
+	Path    string `yaml:"path" toml:"path"`
+}
+
+// TracingConfig sends OpenTelemetry spans of the requests, and of the
+// database calls and queries they make, to Exporter.
+type TracingConfig struct {
+	Enabled bool `yaml:"enabled" toml:"enabled"`
+	// Exporter is otlp, sending the spans over gRPC to Endpoint, stdout, or
+	// file, appending them to File as JSON.
+	Exporter string `yaml:"exporter" toml:"exporter"`
+	Endpoint string `yaml:"endpoint" toml:"endpoint"`
+	// Insecure sends the OTLP spans without TLS.
+	Insecure bool   `yaml:"insecure" toml:"insecure"`
+	File     string `yaml:"file" toml:"file"`
+	// SampleRatio is the share of the traces started by the API that are
+	// recorded. Requests carrying a traceparent follow the decision of the
+	// caller.
+	SampleRatio float64 `yaml:"sample_ratio" toml:"sample_ratio"`
+	ServiceName string  `yaml:"service_name" toml:"service_name"`
+}
+
+// HealthConfig tunes the /readyz probe.
+type HealthConfig struct {
+	// Timeout fails the checks, such as the database ping, taking longer.
+	Timeout Duration `yaml:"timeout" toml:"timeout"`
+}
+
+// tracingExporters are the valid TracingConfig.Exporter.
+var tracingExporters = map[string]bool{
+	"otlp": true, "stdout": true, "file": true,
+}
+
+// RateLimit lets Rate requests per second through, with bursts of up to
+// Burst requests. A zero Rate is unlimited.
+type RateLimit struct {
+	Rate  float64 `yaml:"rate" toml:"rate"`
+	Burst int     `yaml:"burst" toml:"burst"`
+}
+
+// routeMethods are the methods a RateLimitConfig.Routes key may start with.
+var routeMethods = map[string]bool{
+	"GET": true, "HEAD": true, "POST": true, "PUT": true, "PATCH": true, "DELETE": true, "OPTIONS": true,
+}
+
+// tenantSources are the valid TenancyConfig.Sources.
+var tenantSources = map[string]bool{
+	"claim": true, "header": true, "subdomain": true,
+}
+
+// minHMACSecretLen is the shortest HMAC secret accepted, 256 bits.
+const minHMACSecretLen = 32
+
+// Duration is a time.Duration written as "30s" or "5m" in config files.
+type Duration time.Duration
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+func Default() Config {
+	return Config{
+		ListenAddr: ":8080",
+		Server: ServerConfig{
+			ReadHeaderTimeout: Duration(5 * time.Second),
+			ReadTimeout:       Duration(15 * time.Second),
+			WriteTimeout:      Duration(30 * time.Second),
+			IdleTimeout:       Duration(time.Minute),
+			MaxHeaderBytes:    1 << 20,
+			ShutdownTimeout:   Duration(30 * time.Second),
+			TLS: TLSConfig{
+				ReloadInterval: Duration(time.Minute),
+			},
+		},
+		LogLevel:     "info",
+		LogFormat:    "json",
+		LogRedactPII: true,
+		DB: DBConfig{
+			Driver:          "postgres",
+			SQLitePath:      "orderapi.db",
+			Host:            "localhost",
+			Port:            5432,
+			User:            "postgres",
+			Name:            "assignment2db",
+			SSLMode:         "disable",
+			MaxOpenConns:    10,
+			MaxIdleConns:    5,
+			ConnMaxLifetime: Duration(30 * time.Minute),
+			Timeouts: DBTimeouts{
+				Read:  Duration(5 * time.Second),
+				Write: Duration(10 * time.Second),
+				Purge: Duration(5 * time.Minute),
+			},
+		},
+		Purge: PurgeConfig{
+			Retention: Duration(30 * 24 * time.Hour),
+			Interval:  Duration(time.Hour),
+		},
+		Idempotency: IdempotencyConfig{
+			TTL:      Duration(24 * time.Hour),
+			Interval: Duration(time.Hour),
+		},
+		Auth: AuthConfig{
+			Enabled: true,
+			JWT:     JWTConfig{TenantClaim: "tenant"},
+		},
+		Tenancy: TenancyConfig{
+			Sources: []string{"claim", "header"},
+			Header:  "X-Tenant-ID",
+			Burst:   20,
+		},
+		RateLimit: RateLimitConfig{
+			Enabled: true,
+			IP:      RateLimit{Rate: 50, Burst: 100},
+			Read:    RateLimit{Rate: 20, Burst: 40},
+			Write:   RateLimit{Rate: 5, Burst: 10},
+		},
+		Metrics: MetricsConfig{
+			Enabled: true,
+			Path:    "/metrics",
+		},
+		Tracing: TracingConfig{
+			Exporter:    "otlp",
+			Endpoint:    "localhost:4317",
+			File:        "traces.json",
+			SampleRatio: 1,
+			ServiceName: "orderapi",
+		},
+		Health: HealthConfig{
+			Timeout: Duration(2 * time.Second),
+		},
+	}
+}
+
+// setting binds one key of the config to its flag and environment variable.
+// The key "db.host" is the flag -db-host and the variable ORDERAPI_DB_HOST.
+type setting struct {
+	key    string
+	usage  string
+	set    func(string) error
+	isBool bool
+}
+
+// flagValue records a flag's raw value so it can be applied after the file
+// and the environment.
+type flagValue struct {
+	value  string
+	isBool bool
+}
+
+func (f *flagValue) String() string { return f.value }
+
+func (f *flagValue) Set(v string) error {
+	f.value = v
+	return nil
+}
+
+func (f *flagValue) IsBoolFlag() bool { return f.isBool }
+
+func (s setting) flagName() string {
+	return strings.ReplaceAll(s.key, ".", "-")
+}
+
+func (s setting) envName() string {
+	return envPrefix + strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(s.key))
+}
+
+func stringSetting(key, usage string, p *string) setting {
+	return setting{key: key, usage: usage, set: func(v string) error {
+		*p = v
+		return nil
+	}}
+}
+
+func intSetting(key, usage string, p *int) setting {
+	return setting{key: key, usage: usage, set: func(v string) error {
+		parsed, err := strconv.Atoi(v)
+		if err != nil {
+			return err
+		}
+		*p = parsed
+		return nil
+	}}
+}
+
+func boolSetting(key, usage string, p *bool) setting {
+	return setting{key: key, usage: usage, isBool: true, set: func(v string) error {
+		parsed, err := strconv.ParseBool(v)
+		if err != nil {
+			return err
+		}
+		*p = parsed
+		return nil
+	}}
+}
+
+func floatSetting(key, usage string, p *float64) setting {
+	return setting{key: key, usage: usage, set: func(v string) error {
+		parsed, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return err
+		}
+		*p = parsed
+		return nil
+	}}
+}
+
+// listSetting sets a list written comma separated in flags and the
+// environment.
+func listSetting(key, usage string, p *[]string) setting {
+	return setting{key: key, usage: usage, set: func(v string) error {
+		*p = nil
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				*p = append(*p, item)
+			}
+		}
+		return nil
+	}}
+}
+
+func durationSetting(key, usage string, p *Duration) setting {
+	return setting{key: key, usage: usage, set: func(v string) error {
+		return p.UnmarshalText([]byte(v))
+	}}
+}
+
+func (c *Config) settings() []setting {
+	return []setting{
+		stringSetting("listen-addr", "address the HTTP server listens on", &c.ListenAddr),
+		durationSetting("server.read-header-timeout", "time allowed to read request headers, 0 is unlimited", &c.Server.ReadHeaderTimeout),
+		durationSetting("server.read-timeout", "time allowed to read a whole request, 0 is unlimited", &c.Server.ReadTimeout),
+		durationSetting("server.write-timeout", "time allowed to write a response, 0 is unlimited", &c.Server.WriteTimeout),
+		durationSetting("server.idle-timeout", "how long idle keep-alive connections stay open, 0 is unlimited", &c.Server.IdleTimeout),
+		intSetting("server.max-header-bytes", "largest request header accepted", &c.Server.MaxHeaderBytes),
+		durationSetting("server.drain-delay", "how long the server keeps serving, unready, before shutting down", &c.Server.DrainDelay),
+		durationSetting("server.shutdown-timeout", "how long in-flight requests may finish on shutdown", &c.Server.ShutdownTimeout),
+		stringSetting("server.tls.cert-file", "PEM certificate served over TLS, reloaded when it changes", &c.Server.TLS.CertFile),
+		stringSetting("server.tls.key-file", "PEM private key of the TLS certificate", &c.Server.TLS.KeyFile),
+		durationSetting("server.tls.reload-interval", "how often the TLS files are checked for changes", &c.Server.TLS.ReloadInterval),
+		stringSetting("log-level", "one of debug, info, warn, error", &c.LogLevel),
+		stringSetting("log-format", "one of json, text", &c.LogFormat),
+		boolSetting("log-redact-pii", "mask customer names and SQL string values in the logs", &c.LogRedactPII),
+		stringSetting("db.driver", "one of postgres, sqlite, memory", &c.DB.Driver),
+		stringSetting("db.sqlite-path", "sqlite database file, used by the sqlite driver", &c.DB.SQLitePath),
+		stringSetting("db.host", "database host", &c.DB.Host),
+		intSetting("db.port", "database port", &c.DB.Port),
+		stringSetting("db.user", "database user", &c.DB.User),
+		stringSetting("db.password", "database password", &c.DB.Password),
+		stringSetting("db.password-file", "file containing the database password", &c.DB.PasswordFile),
+		stringSetting("db.name", "database name", &c.DB.Name),
+		stringSetting("db.sslmode", "postgres sslmode", &c.DB.SSLMode),
+		intSetting("db.max-open-conns", "maximum open database connections, 0 is unlimited", &c.DB.MaxOpenConns),
+		intSetting("db.max-idle-conns", "maximum idle database connections", &c.DB.MaxIdleConns),
+		durationSetting("db.conn-max-lifetime", "maximum lifetime of a database connection, 0 is unlimited", &c.DB.ConnMaxLifetime),
+		boolSetting("db.auto-migrate", "apply pending migrations at startup", &c.DB.AutoMigrate),
+		durationSetting("db.timeouts.read", "how long a reading store call may take, 0 is unlimited", &c.DB.Timeouts.Read),
+		durationSetting("db.timeouts.write", "how long a writing store call may take, 0 is unlimited", &c.DB.Timeouts.Write),
+		durationSetting("db.timeouts.purge", "how long a background purge may take, 0 is unlimited", &c.DB.Timeouts.Purge),
+		boolSetting("db.row-level-security", "set the tenant of postgres transactions for the row level security policies", &c.DB.RowLevelSecurity),
+		durationSetting("purge.retention", "how long deleted orders stay restorable, 0 disables purging", &c.Purge.Retention),
+		durationSetting("purge.interval", "how often deleted orders past retention are purged", &c.Purge.Interval),
+		durationSetting("idempotency.ttl", "how long Idempotency-Key responses are replayed, 0 ignores the header", &c.Idempotency.TTL),
+		durationSetting("idempotency.interval", "how often expired idempotency keys are deleted", &c.Idempotency.Interval),
+		boolSetting("auth.enabled", "require an API key or JWT on every API request", &c.Auth.Enabled),
+		stringSetting("auth.jwt.jwks-file", "JWKS file with the public keys verifying bearer tokens", &c.Auth.JWT.JWKSFile),
+		stringSetting("auth.jwt.hmac-secret", "HMAC secret verifying bearer tokens", &c.Auth.JWT.HMACSecret),
+		stringSetting("auth.jwt.hmac-secret-file", "file containing the HMAC secret verifying bearer tokens", &c.Auth.JWT.HMACSecretFile),
+		stringSetting("auth.jwt.issuer", "required iss claim of bearer tokens", &c.Auth.JWT.Issuer),
+		stringSetting("auth.jwt.audience", "required aud claim of bearer tokens", &c.Auth.JWT.Audience),
+		stringSetting("auth.jwt.tenant-claim", "claim of bearer tokens holding the tenant", &c.Auth.JWT.TenantClaim),
+		boolSetting("tenancy.enabled", "limit every order request to the orders of its tenant", &c.Tenancy.Enabled),
+		listSetting("tenancy.sources", "comma separated sources of the tenant, in order: claim, header, subdomain", &c.Tenancy.Sources),
+		stringSetting("tenancy.header", "header naming the tenant, used by the header source", &c.Tenancy.Header),
+		stringSetting("tenancy.base-domain", "domain whose subdomains name the tenant, used by the subdomain source", &c.Tenancy.BaseDomain),
+		intSetting("tenancy.max-orders", "how many orders a tenant may have, 0 is unlimited", &c.Tenancy.MaxOrders),
+		floatSetting("tenancy.rate", "order requests per second a tenant may make, 0 is unlimited", &c.Tenancy.Rate),
+		intSetting("tenancy.burst", "order requests a tenant may make at once", &c.Tenancy.Burst),
+		boolSetting("rate-limit.enabled", "limit how often clients call the API", &c.RateLimit.Enabled),
+		floatSetting("rate-limit.ip.rate", "requests per second of each client IP, 0 is unlimited", &c.RateLimit.IP.Rate),
+		intSetting("rate-limit.ip.burst", "requests each client IP may make at once", &c.RateLimit.IP.Burst),
+		floatSetting("rate-limit.read.rate", "GET requests per second of each client, 0 is unlimited", &c.RateLimit.Read.Rate),
+		intSetting("rate-limit.read.burst", "GET requests each client may make at once", &c.RateLimit.Read.Burst),
+		floatSetting("rate-limit.write.rate", "writing requests per second of each client, 0 is unlimited", &c.RateLimit.Write.Rate),
+		intSetting("rate-limit.write.burst", "writing requests each client may make at once", &c.RateLimit.Write.Burst),
+		listSetting("rate-limit.trusted-proxies", "comma separated proxies whose X-Forwarded-For gives the client IP", &c.RateLimit.TrustedProxies),
+		boolSetting("metrics.enabled", "expose Prometheus metrics", &c.Metrics.Enabled),
+		stringSetting("metrics.path", "path of the Prometheus metrics", &c.Metrics.Path),
+		boolSetting("tracing.enabled", "send OpenTelemetry spans of the requests", &c.Tracing.Enabled),
+		stringSetting("tracing.exporter", "where spans are sent: otlp, stdout or file", &c.Tracing.Exporter),
+		stringSetting("tracing.endpoint", "host:port of the OTLP gRPC collector", &c.Tracing.Endpoint),
+		boolSetting("tracing.insecure", "send spans to the OTLP collector without TLS", &c.Tracing.Insecure),
+		stringSetting("tracing.file", "file the spans are appended to by the file exporter", &c.Tracing.File),
+		floatSetting("tracing.sample-ratio", "share of the new traces recorded, from 0 to 1", &c.Tracing.SampleRatio),
+		stringSetting("tracing.service-name", "service.name of the spans", &c.Tracing.ServiceName),
+		durationSetting("health.timeout", "how long each /readyz check may take", &c.Health.Timeout),
+	}
+}
+
+// Load builds the config from the defaults, the config file named by the
+// -config flag or ORDERAPI_CONFIG, the environment and args, then validates it.
+func Load(args []string) (*Config, error) {
+	cfg := Default()
+	settings := cfg.settings()
+
+	fs := flag.NewFlagSet("orderapi", flag.ContinueOnError)
+	configPath := fs.String("config", os.Getenv(envPrefix+"CONFIG"), "path to a YAML or TOML config file")
+	flagValues := make(map[string]*flagValue, len(settings))
+	for _, s := range settings {
+		flagValues[s.flagName()] = &flagValue{isBool: s.isBool}
+		fs.Var(flagValues[s.flagName()], s.flagName(), s.usage+" (env "+s.envName()+")")
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	cfg.Args = fs.Args()
+
+	if *configPath != "" {
+		if err := cfg.loadFile(*configPath); err != nil {
+			return nil, err
+		}
+	}
+	for _, s := range settings {
+		if value, ok := os.LookupEnv(s.envName()); ok {
+			if err := s.set(value); err != nil {
+				return nil, fmt.Errorf("config: %s: %w", s.envName(), err)
+			}
+		}
+	}
+	var flagErr error
+	fs.Visit(func(f *flag.Flag) {
+		for _, s := range settings {
+			if s.flagName() == f.Name && flagErr == nil {
+				if err := s.set(flagValues[f.Name].value); err != nil {
+					flagErr = fmt.Errorf("config: -%s: %w", f.Name, err)
+				}
+			}
+		}
+	})
+	if flagErr != nil {
+		return nil, flagErr
+	}
+
+	if cfg.DB.PasswordFile != "" {
+		password, err := os.ReadFile(cfg.DB.PasswordFile)
+		if err != nil {
+			return nil, fmt.Errorf("config: reading db password file: %w", err)
+		}
+		cfg.DB.Password = strings.TrimRight(string(password), "\r\n")
+	}
+	if cfg.Auth.JWT.HMACSecretFile != "" {
+		secret, err := os.ReadFile(cfg.Auth.JWT.HMACSecretFile)
+		if err != nil {
+			return nil, fmt.Errorf("config: reading auth hmac secret file: %w", err)
+		}
+		cfg.Auth.JWT.HMACSecret = strings.TrimRight(string(secret), "\r\n")
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+func (c *Config) loadFile(path string) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(raw, c)
+	case ".toml":
+		err = toml.Unmarshal(raw, c)
+	default:
+		return fmt.Errorf("config: %s: unknown config file extension, use .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return fmt.Errorf("config: %s: %w", path, err)
+	}
+	return nil
+}
+
+var sslModes = map[string]bool{
+	"disable": true, "allow": true, "prefer": true, "require": true, "verify-ca": true, "verify-full": true,
+}
+
+var logLevels = map[string]bool{
+	"debug": true, "info": true, "warn": true, "error": true,
+}
+
+// Validate reports every invalid setting at once.
+func (c *Config) Validate() error {
+	var errs []string
+	if _, _, err := net.SplitHostPort(c.ListenAddr); err != nil {
+		errs = append(errs, fmt.Sprintf("listen-addr %q: %v", c.ListenAddr, err))
+	}
+	timeouts := []struct {
+		key string
+		d   Duration
+	}{
+		{"server.read-header-timeout", c.Server.ReadHeaderTimeout},
+		{"server.read-timeout", c.Server.ReadTimeout},
+		{"server.write-timeout", c.Server.WriteTimeout},
+		{"server.idle-timeout", c.Server.IdleTimeout},
+		{"server.drain-delay", c.Server.DrainDelay},
+	}
+	for _, t := range timeouts {
+		if t.d < 0 {
+			errs = append(errs, t.key+" must not be negative")
+		}
+	}
+	if c.Server.MaxHeaderBytes <= 0 {
+		errs = append(errs, "server.max-header-bytes must be positive")
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		errs = append(errs, "server.shutdown-timeout must be positive")
+	}
+	if (c.Server.TLS.CertFile == "") != (c.Server.TLS.KeyFile == "") {
+		errs = append(errs, "server.tls.cert-file and server.tls.key-file must be set together")
+	}
+	if c.Server.TLS.CertFile != "" && c.Server.TLS.ReloadInterval <= 0 {
+		errs = append(errs, "server.tls.reload-interval must be positive")
+	}
+	if !logLevels[c.LogLevel] {
+		errs = append(errs, fmt.Sprintf("log-level %q: must be one of debug, info, warn, error", c.LogLevel))
+	}
+	if c.LogFormat != "json" && c.LogFormat != "text" {
+		errs = append(errs, fmt.Sprintf("log-format %q: must be one of json, text", c.LogFormat))
+	}
+	switch c.DB.Driver {
+	case "postgres":
+		if c.DB.Host == "" {
+			errs = append(errs, "db.host is empty")
+		}
+		if c.DB.Port < 1 || c.DB.Port > 65535 {
+			errs = append(errs, fmt.Sprintf("db.port %d: must be between 1 and 65535", c.DB.Port))
+		}
+		if c.DB.User == "" {
+			errs = append(errs, "db.user is empty")
+		}
+		if c.DB.Name == "" {
+			errs = append(errs, "db.name is empty")
+		}
+		if !sslModes[c.DB.SSLMode] {
+			errs = append(errs, fmt.Sprintf("db.sslmode %q: must be one of disable, allow, prefer, require, verify-ca, verify-full", c.DB.SSLMode))
+		}
+	case "sqlite":
+		if c.DB.SQLitePath == "" {
+			errs = append(errs, "db.sqlite-path is empty")
+		}
+	case "memory":
+	default:
+		errs = append(errs, fmt.Sprintf("db.driver %q: must be one of postgres, sqlite, memory", c.DB.Driver))
+	}
+	if c.DB.MaxOpenConns < 0 {
+		errs = append(errs, "db.max-open-conns must not be negative")
+	}
+	if c.DB.MaxIdleConns < 0 {
+		errs = append(errs, "db.max-idle-conns must not be negative")
+	}
+	if c.DB.MaxOpenConns > 0 && c.DB.MaxIdleConns > c.DB.MaxOpenConns {
+		errs = append(errs, "db.max-idle-conns must not exceed db.max-open-conns")
+	}
+	if c.DB.ConnMaxLifetime < 0 {
+		errs = append(errs, "db.conn-max-lifetime must not be negative")
+	}
+	if c.DB.Timeouts.Read < 0 || c.DB.Timeouts.Write < 0 || c.DB.Timeouts.Purge < 0 {
+		errs = append(errs, "db.timeouts must not be negative")
+	}
+	operations := make([]string, 0, len(c.DB.Timeouts.Operations))
+	for operation := range c.DB.Timeouts.Operations {
+		operations = append(operations, operation)
+	}
+	sort.Strings(operations)
+	for _, operation := range operations {
+		if c.DB.Timeouts.Operations[operation] < 0 {
+			errs = append(errs, fmt.Sprintf("db.timeouts.operations %q must not be negative", operation))
+		}
+	}
+	if c.Purge.Retention < 0 {
+		errs = append(errs, "purge.retention must not be negative")
+	}
+	if c.Purge.Retention > 0 && c.Purge.Interval <= 0 {
+		errs = append(errs, "purge.interval must be positive")
+	}
+	if c.Idempotency.TTL < 0 {
+		errs = append(errs, "idempotency.ttl must not be negative")
+	}
+	if c.Idempotency.TTL > 0 && c.Idempotency.Interval <= 0 {
+		errs = append(errs, "idempotency.interval must be positive")
+	}
+	if c.Auth.JWT.JWKSFile != "" && c.Auth.JWT.HMACSecret != "" {
+		errs = append(errs, "auth.jwt.jwks-file and auth.jwt.hmac-secret cannot be used together")
+	}
+	if c.Auth.JWT.HMACSecret != "" && len(c.Auth.JWT.HMACSecret) < minHMACSecretLen {
+		errs = append(errs, fmt.Sprintf("auth.jwt.hmac-secret must be at least %d bytes", minHMACSecretLen))
+	}
+	if c.DB.RowLevelSecurity && (c.DB.Driver != "postgres" || !c.Tenancy.Enabled) {
+		errs = append(errs, "db.row-level-security needs db.driver postgres and tenancy.enabled")
+	}
+	if c.Tenancy.Enabled {
+		if len(c.Tenancy.Sources) == 0 {
+			errs = append(errs, "tenancy.sources is empty")
+		}
+		for _, source := range c.Tenancy.Sources {
+			if !tenantSources[source] {
+				errs = append(errs, fmt.Sprintf("tenancy.sources %q: must be one of claim, header, subdomain", source))
+			}
+			if source == "header" && c.Tenancy.Header == "" {
+				errs = append(errs, "tenancy.header is empty")
+			}
+			if source == "subdomain" && c.Tenancy.BaseDomain == "" {
+				errs = append(errs, "tenancy.base-domain is empty")
+			}
+		}
+	}
+	if c.Tenancy.MaxOrders < 0 {
+		errs = append(errs, "tenancy.max-orders must not be negative")
+	}
+	errs = append(errs, RateLimit{Rate: c.Tenancy.Rate, Burst: c.Tenancy.Burst}.validate("tenancy.")...)
+	errs = append(errs, c.RateLimit.IP.validate("rate-limit.ip.")...)
+	errs = append(errs, c.RateLimit.Read.validate("rate-limit.read.")...)
+	errs = append(errs, c.RateLimit.Write.validate("rate-limit.write.")...)
+	routes := make([]string, 0, len(c.RateLimit.Routes))
+	for route := range c.RateLimit.Routes {
+		routes = append(routes, route)
+	}
+	sort.Strings(routes)
+	for _, route := range routes {
+		limit := c.RateLimit.Routes[route]
+		method, path, _ := strings.Cut(route, " ")
+		if !routeMethods[method] || !strings.HasPrefix(path, "/") {
+			errs = append(errs, fmt.Sprintf("rate-limit.routes %q: must be a method and a path, such as \"POST /orders\"", route))
+		}
+		errs = append(errs, limit.validate(fmt.Sprintf("rate-limit.routes %q ", route))...)
+	}
+	if c.Metrics.Enabled && !strings.HasPrefix(c.Metrics.Path, "/") {
+		errs = append(errs, fmt.Sprintf("metrics.path %q: must start with /", c.Metrics.Path))
+	}
+	if c.Metrics.Enabled && (c.Metrics.Path == "/healthz" || c.Metrics.Path == "/readyz") {
+		errs = append(errs, fmt.Sprintf("metrics.path %q: taken by the health probes", c.Metrics.Path))
+	}
+	if c.Health.Timeout <= 0 {
+		errs = append(errs, "health.timeout must be positive")
+	}
+	if c.Tracing.Enabled {
+		if !tracingExporters[c.Tracing.Exporter] {
+			errs = append(errs, fmt.Sprintf("tracing.exporter %q: must be one of otlp, stdout, file", c.Tracing.Exporter))
+		}
+		if c.Tracing.Exporter == "otlp" && c.Tracing.Endpoint == "" {
+			errs = append(errs, "tracing.endpoint is empty")
+		}
+		if c.Tracing.Exporter == "file" && c.Tracing.File == "" {
+			errs = append(errs, "tracing.file is empty")
+		}
+		if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+			errs = append(errs, "tracing.sample-ratio must be between 0 and 1")
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New("config: " + strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func (l RateLimit) validate(prefix string) []string {
+	var errs []string
+	if l.Rate < 0 {
+		errs = append(errs, prefix+"rate must not be negative")
+	}
+	if l.Rate > 0 && l.Burst < 1 {
+		errs = append(errs, prefix+"burst must be positive")
+	}
+	return errs
+}
+
+// DSN returns the postgres connection string for c.
+func (c DBConfig) DSN() string {
+	quote := func(v string) string {
+		return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(v) + "'"
+	}
+	return fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%d sslmode=%s",
+		quote(c.Host), quote(c.User), quote(c.Password), quote(c.Name), c.Port, quote(c.SSLMode))
+}
@@ -0,0 +1,126 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+
+	"assignment2.id/orderapi/apierror"
+	"assignment2.id/orderapi/database"
+	"assignment2.id/orderapi/models"
+	"assignment2.id/orderapi/validation"
+	"github.com/gin-gonic/gin"
+)
+
+// ErrorHandler answers the errors the handlers abort with as problem+json.
+func ErrorHandler() gin.HandlerFunc {
+	return apierror.Middleware(translateError)
+}
+
+// Recovery answers the requests whose handler panics as an internal error,
+// logged by ErrorHandler with the stack of the panic. It goes right after
+// ErrorHandler, which writes the problem once it returns.
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(io.Discard, func(ctx *gin.Context, recovered any) {
+		err := fmt.Errorf("panic: %v\n%s", recovered, debug.Stack())
+		apierror.Abort(ctx, apierror.New(http.StatusInternalServerError, apierror.CodeInternal).Wrap(err))
+	})
+}
+
+// translateError maps the errors of the store, the models and the
+// validation to API errors.
+func translateError(err error) *apierror.Error {
+	var violations validation.Errors
+	if errors.As(err, &violations) {
+		return validationError(violations)
+	}
+	switch {
+	case errors.Is(err, database.ErrRecordNotFound):
+		return apierror.New(http.StatusNotFound, apierror.CodeOrderNotFound).WithField("orderID")
+	case errors.Is(err, database.ErrAPIKeyNotFound):
+		return apierror.New(http.StatusNotFound, apierror.CodeAPIKeyNotFound).WithField("keyID")
+	case errors.Is(err, database.ErrItemNotFound):
+		return apierror.New(http.StatusNotFound, apierror.CodeItemNotFound).WithField("itemID")
+	case errors.Is(err, database.ErrDuplicateKey):
+		return apierror.New(http.StatusConflict, apierror.CodeAlreadyExists)
+	case errors.Is(err, database.ErrNotDeleted):
+		return apierror.New(http.StatusConflict, apierror.CodeOrderNotDeleted)
+	case errors.Is(err, database.ErrVersionMismatch):
+		return apierror.New(http.StatusPreconditionFailed, apierror.CodeVersionMismatch).WithField("If-Match")
+	case errors.Is(err, database.ErrItemNotInOrder):
+		return apierror.New(http.StatusUnprocessableEntity, apierror.CodeItemNotInOrder).WithField("/Items")
+	case errors.Is(err, database.ErrInvalidCursor):
+		return apierror.New(http.StatusBadRequest, apierror.CodeInvalidCursor).WithField("cursor")
+	case errors.Is(err, database.ErrTimeout):
+		return apierror.New(http.StatusGatewayTimeout, apierror.CodeDatabaseTimeout)
+	case errors.Is(err, database.ErrCanceled):
+		return apierror.New(apierror.StatusClientClosedRequest, apierror.CodeRequestCanceled)
+	case errors.Is(err, database.ErrInvalidSort):
+		return apierror.New(http.StatusBadRequest, apierror.CodeInvalidSort).WithField("sort")
+	case errors.Is(err, models.ErrCustomerNameEmpty):
+		return apierror.New(http.StatusBadRequest, apierror.CodeCustomerNameEmpty).WithField("/CustomerName")
+	case errors.Is(err, models.ErrItemCodeEmpty):
+		return apierror.New(http.StatusBadRequest, apierror.CodeItemCodeEmpty).WithField("/ItemCode")
+	case errors.Is(err, ErrOrderedAtNull):
+		return apierror.New(http.StatusUnprocessableEntity, apierror.CodeOrderedAtNull).WithField("/OrderedAt")
+	case errors.Is(err, ErrIDImmutable):
+		return apierror.New(http.StatusUnprocessableEntity, apierror.CodeIDImmutable).WithField("/ID")
+	}
+	return nil
+}
+
+var ruleCodes = map[string]apierror.Code{
+	validation.RuleRequired:      apierror.CodeRequired,
+	validation.RuleTooLong:       apierror.CodeTooLong,
+	validation.RuleTooMany:       apierror.CodeTooMany,
+	validation.RuleNotPositive:   apierror.CodeNotPositive,
+	validation.RuleInvalidFormat: apierror.CodeInvalidFormat,
+	validation.RuleTooFarAhead:   apierror.CodeTooFarAhead,
+	validation.RuleNotAllowed:    apierror.CodeNotAllowed,
+}
+
+// validationError answers every violation at once with 422.
+func validationError(violations validation.Errors) *apierror.Error {
+	apiErr := apierror.New(http.StatusUnprocessableEntity, apierror.CodeValidationFailed, len(violations)).Wrap(violations)
+	for _, v := range violations {
+		code, ok := ruleCodes[v.Rule]
+		if !ok {
+			code = apierror.Code(v.Rule)
+		}
+		apiErr.Violations = append(apiErr.Violations,
+			apierror.New(http.StatusUnprocessableEntity, code, v.Field, v.Param).WithField(v.Field))
+	}
+	return apiErr
+}
+
+// BindStrict decodes the JSON request body into v, rejecting unknown fields.
+func BindStrict(ctx *gin.Context, v interface{}) error {
+	body, err := io.ReadAll(ctx.Request.Body)
+	if err != nil {
+		return err
+	}
+	return DecodeStrict(body, v)
+}
+
+// DecodeStrict decodes the JSON raw into v, rejecting unknown fields.
+func DecodeStrict(raw []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(v)
+}
+
+// PathID parses the path parameter param as an ID, aborting with 400 when
+// it is not one.
+func PathID(ctx *gin.Context, param string) (uint, bool) {
+	parsed, err := strconv.ParseUint(ctx.Param(param), 10, 0)
+	if err != nil {
+		apierror.Abort(ctx, apierror.New(http.StatusBadRequest, apierror.CodeInvalidPathParam, param).WithField(param).Wrap(err))
+		return 0, false
+	}
+	return uint(parsed), true
+}
@@ -0,0 +1,86 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"assignment2.id/orderapi/apierror"
+	"assignment2.id/orderapi/database"
+	"assignment2.id/orderapi/models"
+	"assignment2.id/orderapi/validation"
+)
+
+func TestTranslateError(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+		code   apierror.Code
+		field  string
+	}{
+		{database.ErrRecordNotFound, http.StatusNotFound, apierror.CodeOrderNotFound, "orderID"},
+		{database.ErrAPIKeyNotFound, http.StatusNotFound, apierror.CodeAPIKeyNotFound, "keyID"},
+		{database.ErrItemNotFound, http.StatusNotFound, apierror.CodeItemNotFound, "itemID"},
+		{database.ErrDuplicateKey, http.StatusConflict, apierror.CodeAlreadyExists, ""},
+		{database.ErrNotDeleted, http.StatusConflict, apierror.CodeOrderNotDeleted, ""},
+		{database.ErrVersionMismatch, http.StatusPreconditionFailed, apierror.CodeVersionMismatch, "If-Match"},
+		{fmt.Errorf("item 7: %w", database.ErrItemNotInOrder), http.StatusUnprocessableEntity, apierror.CodeItemNotInOrder, "/Items"},
+		{database.ErrInvalidCursor, http.StatusBadRequest, apierror.CodeInvalidCursor, "cursor"},
+		{fmt.Errorf("GetOrderById: %w", database.ErrTimeout), http.StatusGatewayTimeout, apierror.CodeDatabaseTimeout, ""},
+		{fmt.Errorf("GetOrderById: %w", database.ErrCanceled), apierror.StatusClientClosedRequest, apierror.CodeRequestCanceled, ""},
+		{database.ErrInvalidSort, http.StatusBadRequest, apierror.CodeInvalidSort, "sort"},
+		{models.ErrCustomerNameEmpty, http.StatusBadRequest, apierror.CodeCustomerNameEmpty, "/CustomerName"},
+		{models.ErrItemCodeEmpty, http.StatusBadRequest, apierror.CodeItemCodeEmpty, "/ItemCode"},
+		{ErrOrderedAtNull, http.StatusUnprocessableEntity, apierror.CodeOrderedAtNull, "/OrderedAt"},
+		{ErrIDImmutable, http.StatusUnprocessableEntity, apierror.CodeIDImmutable, "/ID"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.err.Error(), func(t *testing.T) {
+			got := translateError(tt.err)
+			if got == nil {
+				t.Fatal("got nil, want an API error")
+			}
+			if got.Status != tt.status || got.Code != tt.code || got.Field != tt.field {
+				t.Errorf("got %d %q %q, want %d %q %q", got.Status, got.Code, got.Field, tt.status, tt.code, tt.field)
+			}
+		})
+	}
+}
+
+func TestTranslateUnknownError(t *testing.T) {
+	if got := translateError(errors.New("pq: connection refused")); got != nil {
+		t.Errorf("got %+v, want nil for an unknown error", got)
+	}
+}
+
+func TestTranslateValidationError(t *testing.T) {
+	violations := validation.Errors{
+		{Field: "/CustomerName", Rule: validation.RuleRequired, Param: ""},
+		{Field: "/Items/0/Quantity", Rule: validation.RuleNotPositive, Param: "0"},
+		{Field: "/Status", Rule: "custom_rule"},
+	}
+	got := translateError(fmt.Errorf("patch: %w", violations))
+	if got == nil {
+		t.Fatal("got nil, want an API error")
+	}
+	if got.Status != http.StatusUnprocessableEntity || got.Code != apierror.CodeValidationFailed {
+		t.Errorf("got %d %q, want 422 %q", got.Status, got.Code, apierror.CodeValidationFailed)
+	}
+	want := []struct {
+		code  apierror.Code
+		field string
+	}{
+		{apierror.CodeRequired, "/CustomerName"},
+		{apierror.CodeNotPositive, "/Items/0/Quantity"},
+		{"custom_rule", "/Status"},
+	}
+	if len(got.Violations) != len(want) {
+		t.Fatalf("got %d violations, want %d", len(got.Violations), len(want))
+	}
+	for i, w := range want {
+		if v := got.Violations[i]; v.Code != w.code || v.Field != w.field {
+			t.Errorf("violation %d is %q %q, want %q %q", i, v.Code, v.Field, w.code, w.field)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"assignment2.id/orderapi/apierror"
+	"assignment2.id/orderapi/database"
+	"github.com/gin-gonic/gin"
+)
+
+// ETag is the entity tag of an order at version.
+func ETag(version uint) string {
+	return fmt.Sprintf(`"%d"`, version)
+}
+
+// parseETags splits an If-Match or If-None-Match header into its entity
+// tags. Weak tags keep their W/ prefix.
+func parseETags(header string) []string {
+	var tags []string
+	for _, tag := range strings.Split(header, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// tagVersion returns the version of a strong entity tag made by ETag.
+func tagVersion(tag string) (uint, bool) {
+	if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+		return 0, false
+	}
+	version, err := strconv.ParseUint(tag[1:len(tag)-1], 10, 0)
+	if err != nil || version == 0 {
+		return 0, false
+	}
+	return uint(version), true
+}
+
+// NotModified reports whether the If-None-Match header of the request
+// matches version, comparing weakly as RFC 9110 asks.
+func NotModified(ctx *gin.Context, version uint) bool {
+	for _, tag := range parseETags(ctx.GetHeader("If-None-Match")) {
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == ETag(version) {
+			return true
+		}
+	}
+	return false
+}
+
+// RequireIfMatch returns the version of order id the If-Match header of the
+// request allows a write to, 0 for "*". It aborts with 428 when the header
+// is missing and with 412 when no tag can match.
+func RequireIfMatch(ctx *gin.Context, store database.OrderStore, id uint) (uint, bool) {
+	header := ctx.GetHeader("If-Match")
+	if header == "" {
+		apierror.Abort(ctx, apierror.New(http.StatusPreconditionRequired, apierror.CodeIfMatchRequired).WithField("If-Match"))
+		return 0, false
+	}
+	var versions []uint
+	for _, tag := range parseETags(header) {
+		if tag == "*" {
+			return 0, true
+		}
+		// Weak tags never match under the strong comparison If-Match uses.
+		if version, ok := tagVersion(tag); ok {
+			versions = append(versions, version)
+		}
+	}
+	if len(versions) == 1 {
+		return versions[0], true
+	}
+	if len(versions) > 1 {
+		// The store checks a single version, pick the one the order is at.
+		order, err := store.GetOrderById(id)
+		if errors.Is(err, database.ErrRecordNotFound) {
+			// Let the store answer that the order is missing.
+			return versions[0], true
+		}
+		if err != nil {
+			apierror.Abort(ctx, err)
+			return 0, false
+		}
+		for _, version := range versions {
+			if version == order.Version {
+				return version, true
+			}
+		}
+	}
+	apierror.Abort(ctx, database.ErrVersionMismatch)
+	return 0, false
+}
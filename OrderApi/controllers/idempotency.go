@@ -0,0 +1,110 @@
+package controllers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"assignment2.id/orderapi/apierror"
+	"assignment2.id/orderapi/database"
+	"assignment2.id/orderapi/models"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	IdempotencyKeyHeader = "Idempotency-Key"
+	maxIdempotencyKeyLen = 255
+)
+
+// Idempotency creates orders, replaying the response to a request retried
+// with the same Idempotency-Key header instead of creating another order.
+type Idempotency struct {
+	// TTL is how long a response is replayed, 0 ignores the header.
+	TTL time.Duration
+	// Quota is checked before an order is created, a replayed response
+	// creates none.
+	Quota Quota
+}
+
+// CreateOrder creates order, decoded from body, in store and answers 201
+// with the response render makes of it. A request with an Idempotency-Key
+// answered within the TTL gets that answer again, or 422 when its body
+// differed. version tells apart the API versions, which render different
+// responses. Keys are per owner and tenant of the order, so principals never
+// see each other's responses.
+func (i Idempotency) CreateOrder(ctx *gin.Context, store database.OrderStore, version string, body []byte, order *models.Order, render func(order *models.Order) interface{}) {
+	key := ctx.GetHeader(IdempotencyKeyHeader)
+	if key == "" || i.TTL <= 0 {
+		if !i.Quota.Check(ctx, store) {
+			return
+		}
+		if err := store.CreateOrder(order); err != nil {
+			apierror.Abort(ctx, err)
+			return
+		}
+		ctx.Header("ETag", ETag(order.Version))
+		ctx.JSON(http.StatusCreated, render(order))
+		return
+	}
+	if len(key) > maxIdempotencyKeyLen {
+		apierror.Abort(ctx, apierror.New(http.StatusBadRequest, apierror.CodeIdempotencyKeyLong, maxIdempotencyKeyLen).WithField(IdempotencyKeyHeader))
+		return
+	}
+	hash := sha256.Sum256(append([]byte(version+"\n"), body...))
+	owner := order.Owner
+	if order.TenantID != "" {
+		owner = order.TenantID + "\n" + owner
+	}
+	ownedKey := sha256.Sum256([]byte(owner + "\n" + key))
+	record := database.IdempotencyKey{
+		Key:         hex.EncodeToString(ownedKey[:]),
+		RequestHash: hex.EncodeToString(hash[:]),
+		StatusCode:  http.StatusCreated,
+	}
+	since := time.Now().Add(-i.TTL)
+	if replay(ctx, store, record, since) || !i.Quota.Check(ctx, store) {
+		return
+	}
+	err := store.CreateOrderOnce(order, &record, since, func(order *models.Order) ([]byte, error) {
+		return json.Marshal(render(order))
+	})
+	if errors.Is(err, database.ErrIdempotencyKeyUsed) {
+		// A concurrent request with the key won the race.
+		if replay(ctx, store, record, since) {
+			return
+		}
+		apierror.Abort(ctx, apierror.New(http.StatusConflict, apierror.CodeIdempotencyInUse).WithField(IdempotencyKeyHeader))
+		return
+	}
+	if err != nil {
+		apierror.Abort(ctx, err)
+		return
+	}
+	ctx.Header("ETag", ETag(order.Version))
+	ctx.Data(record.StatusCode, gin.MIMEJSON+"; charset=utf-8", record.Response)
+}
+
+// replay answers with the response and ETag recorded for the key of record,
+// or with 422 when the key was recorded for another request body. It reports
+// false, answering nothing, when the key has no live record.
+func replay(ctx *gin.Context, store database.OrderStore, record database.IdempotencyKey, since time.Time) bool {
+	stored, err := store.GetIdempotencyKey(record.Key, since)
+	if errors.Is(err, database.ErrRecordNotFound) {
+		return false
+	}
+	if err != nil {
+		apierror.Abort(ctx, err)
+		return true
+	}
+	if stored.RequestHash != record.RequestHash {
+		apierror.Abort(ctx, apierror.New(http.StatusUnprocessableEntity, apierror.CodeIdempotencyReused).WithField(IdempotencyKeyHeader))
+		return true
+	}
+	ctx.Header("Idempotent-Replayed", "true")
+	ctx.Header("ETag", ETag(stored.OrderVersion))
+	ctx.Data(stored.StatusCode, gin.MIMEJSON+"; charset=utf-8", stored.Response)
+	return true
+}
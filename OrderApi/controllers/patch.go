@@ -0,0 +1,75 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"assignment2.id/orderapi/apierror"
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	MergePatchType = "application/merge-patch+json"
+	JSONPatchType  = "application/json-patch+json"
+)
+
+var ErrOrderedAtNull error = errors.New("OrderedAt tidak bisa dikosongkan.")
+var ErrIDImmutable error = errors.New("ID tidak bisa diubah.")
+
+// ReadPatch reads a JSON Merge Patch or JSON Patch request body, answering
+// 415 or 400 when it is neither. It returns the function applying the patch
+// to a JSON document.
+func ReadPatch(ctx *gin.Context) (func(doc []byte) ([]byte, error), bool) {
+	contentType := ctx.ContentType()
+	if contentType != MergePatchType && contentType != JSONPatchType {
+		apierror.Abort(ctx, apierror.New(http.StatusUnsupportedMediaType, apierror.CodeUnsupportedMedia, MergePatchType, JSONPatchType).WithField("Content-Type"))
+		return nil, false
+	}
+	body, err := io.ReadAll(ctx.Request.Body)
+	if err != nil {
+		apierror.Abort(ctx, apierror.InvalidBody(err))
+		return nil, false
+	}
+	if contentType == MergePatchType {
+		if !json.Valid(body) {
+			apierror.Abort(ctx, apierror.New(http.StatusBadRequest, apierror.CodeMalformedBody))
+			return nil, false
+		}
+		return func(doc []byte) ([]byte, error) {
+			return jsonpatch.MergePatch(doc, body)
+		}, true
+	}
+	patch, err := jsonpatch.DecodePatch(body)
+	if err != nil {
+		apierror.Abort(ctx, apierror.New(http.StatusBadRequest, apierror.CodeMalformedPatch, err.Error()).Wrap(err))
+		return nil, false
+	}
+	return patch.Apply, true
+}
+
+// PatchError returns the error answered when a patch cannot be applied
+// because of err.
+func PatchError(err error) error {
+	switch {
+	case errors.Is(rootCause(err), jsonpatch.ErrTestFailed):
+		return apierror.New(http.StatusConflict, apierror.CodePatchTestFailed).Wrap(err)
+	case errors.Is(err, ErrOrderedAtNull), errors.Is(err, ErrIDImmutable):
+		return err
+	}
+	return apierror.New(http.StatusUnprocessableEntity, apierror.CodeInvalidPatch, err.Error()).Wrap(err)
+}
+
+// rootCause unwraps the errors of json-patch, which are wrapped with
+// github.com/pkg/errors and so invisible to errors.Is.
+func rootCause(err error) error {
+	for {
+		causer, ok := err.(interface{ Cause() error })
+		if !ok {
+			return err
+		}
+		err = causer.Cause()
+	}
+}
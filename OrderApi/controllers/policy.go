@@ -0,0 +1,34 @@
+package controllers
+
+import (
+	"assignment2.id/orderapi/apierror"
+	"assignment2.id/orderapi/auth"
+	"assignment2.id/orderapi/database"
+	"assignment2.id/orderapi/policy"
+	"assignment2.id/orderapi/tenancy"
+	"github.com/gin-gonic/gin"
+)
+
+// Authorize returns store scoped to the orders of the tenant of the request
+// the principal may do action on, and running in the context of the request,
+// aborting with 403 when it may do it on none.
+func Authorize(ctx *gin.Context, store database.OrderStore, action policy.Action) (database.OrderStore, bool) {
+	scope, err := policy.Authorize(auth.PrincipalFrom(ctx), action)
+	if err != nil {
+		apierror.Abort(ctx, err)
+		return nil, false
+	}
+	scope.Tenant = tenancy.From(ctx)
+	return store.Scoped(scope).WithContext(ctx.Request.Context()), true
+}
+
+// Require aborts with 403 the requests whose principal may not do action.
+func Require(action policy.Action) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if _, err := policy.Authorize(auth.PrincipalFrom(ctx), action); err != nil {
+			apierror.Abort(ctx, err)
+			return
+		}
+		ctx.Next()
+	}
+}
@@ -0,0 +1,76 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"assignment2.id/orderapi/apierror"
+	"assignment2.id/orderapi/auth"
+	"assignment2.id/orderapi/database"
+	"assignment2.id/orderapi/models"
+	"assignment2.id/orderapi/policy"
+	"github.com/gin-gonic/gin"
+)
+
+func TestAuthorize(t *testing.T) {
+	store := database.NewMemoryStore()
+	for _, owner := range []string{"user", "bob"} {
+		order := models.Order{CustomerName: owner, Owner: owner, Items: []models.Item{}}
+		if err := store.CreateOrder(&order); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Each key is named after its role, the user owns one of the two orders.
+	for _, role := range auth.Roles {
+		if err := store.CreateAPIKey(&database.APIKey{Name: role, Hash: auth.HashAPIKey(role), Roles: role}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	authenticator := &auth.Authenticator{Keys: store}
+	router.Use(ErrorHandler(), authenticator.Middleware())
+	router.GET("/:action", func(ctx *gin.Context) {
+		scoped, ok := Authorize(ctx, store, policy.Action(ctx.Param("action")))
+		if !ok {
+			return
+		}
+		page, err := scoped.ListOrders(database.OrderListQuery{Limit: 10})
+		if err != nil {
+			apierror.Abort(ctx, err)
+			return
+		}
+		ctx.String(http.StatusOK, strconv.Itoa(len(page.Orders)))
+	})
+
+	// want is how many of the orders each role may act on per action, -1
+	// for forbidden.
+	want := map[string]map[policy.Action]int{
+		auth.RoleUser:    {policy.ReadOrder: 1, policy.CreateOrder: 1, policy.UpdateOrder: 1, policy.DeleteOrder: -1, policy.RestoreOrder: -1, policy.PurgeOrder: -1, policy.ManageKeys: -1},
+		auth.RoleSupport: {policy.ReadOrder: 2, policy.CreateOrder: -1, policy.UpdateOrder: -1, policy.DeleteOrder: -1, policy.RestoreOrder: -1, policy.PurgeOrder: -1, policy.ManageKeys: -1},
+		auth.RoleAdmin:   {policy.ReadOrder: 2, policy.CreateOrder: 2, policy.UpdateOrder: 2, policy.DeleteOrder: 2, policy.RestoreOrder: 2, policy.PurgeOrder: 2, policy.ManageKeys: 2},
+	}
+	for role, counts := range want {
+		for action, count := range counts {
+			t.Run(role+"/"+string(action), func(t *testing.T) {
+				req := httptest.NewRequest(http.MethodGet, "/"+string(action), nil)
+				req.Header.Set(auth.APIKeyHeader, role)
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, req)
+				if count < 0 {
+					if w.Code != http.StatusForbidden {
+						t.Errorf("got status %d, want 403", w.Code)
+					}
+					return
+				}
+				if w.Code != http.StatusOK || w.Body.String() != fmt.Sprint(count) {
+					t.Errorf("got %d %q, want %d orders", w.Code, w.Body, count)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package controllers
+
+import (
+	"net/http"
+
+	"assignment2.id/orderapi/apierror"
+	"assignment2.id/orderapi/database"
+	"assignment2.id/orderapi/tenancy"
+	"github.com/gin-gonic/gin"
+)
+
+// Quota limits how many orders each tenant may have, or the server with
+// tenancy disabled.
+type Quota struct {
+	// MaxOrders is the limit, 0 is unlimited. Deleted orders do not count.
+	MaxOrders int
+}
+
+// Check aborts with 403 when the tenant of the request already has
+// MaxOrders orders. It counts before the order is created, so concurrent
+// requests may overshoot the quota by a few orders.
+func (q Quota) Check(ctx *gin.Context, store database.OrderStore) bool {
+	if q.MaxOrders <= 0 {
+		return true
+	}
+	page, err := store.Scoped(database.Scope{Tenant: tenancy.From(ctx)}).ListOrders(database.OrderListQuery{Limit: 1})
+	if err != nil {
+		apierror.Abort(ctx, err)
+		return false
+	}
+	if page.Total >= int64(q.MaxOrders) {
+		apierror.Abort(ctx, apierror.New(http.StatusForbidden, apierror.CodeOrderQuotaExceeded, q.MaxOrders))
+		return false
+	}
+	return true
+}
@@ -0,0 +1,177 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+
+	"assignment2.id/orderapi/apierror"
+	"assignment2.id/orderapi/auth"
+	"assignment2.id/orderapi/controllers"
+	"assignment2.id/orderapi/database"
+	"assignment2.id/orderapi/policy"
+	"assignment2.id/orderapi/tracing"
+	"assignment2.id/orderapi/validation"
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyController serves the /admin/api-keys routes, managing the API keys
+// clients authenticate with. Every route needs the admin role. An admin
+// limited to a tenant only manages the keys of that tenant.
+type APIKeyController struct {
+	store database.OrderStore
+}
+
+func NewAPIKeyController(store database.OrderStore) *APIKeyController {
+	return &APIKeyController{store: store}
+}
+
+// Register adds the routes of the controller to router, each handler
+// recording a span.
+func (c *APIKeyController) Register(router gin.IRouter) {
+	admin := router.Group("/admin/api-keys", controllers.Require(policy.ManageKeys), tracing.Handler())
+	admin.POST("", c.CreateAPIKey)
+	admin.GET("", c.ListAPIKeys)
+	admin.DELETE("/:keyID", c.RevokeAPIKey)
+}
+
+type APIKeyH struct {
+	APIKey CreatedAPIKeyResponse `json:"api_key"`
+}
+
+type APIKeysH struct {
+	APIKeys []APIKeyResponse `json:"api_keys"`
+}
+
+// CreateAPIKey godoc
+// @Summary      Create an API key
+// @Description  create an API key for a client. The key is only shown in this response, only its hash is stored.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        key body APIKeyRequest true "Name and roles of the key."
+// @Success      201  {object}  APIKeyH
+// @Failure      400  {object}  apierror.Problem
+// @Failure      401  {object}  apierror.Problem
+// @Failure      403  {object}  apierror.Problem
+// @Failure      422  {object}  apierror.Problem
+// @Failure      429  {object}  apierror.Problem
+// @Failure      500  {object}  apierror.Problem
+// @Failure      504  {object}  apierror.Problem
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /admin/api-keys [post]
+func (c *APIKeyController) CreateAPIKey(ctx *gin.Context) {
+	var body APIKeyRequest
+	if err := controllers.BindStrict(ctx, &body); err != nil {
+		apierror.Abort(ctx, apierror.InvalidBody(err))
+		return
+	}
+	if err := validation.Struct(&body); err != nil {
+		apierror.Abort(ctx, err)
+		return
+	}
+	tenant := body.Tenant
+	if principal := auth.PrincipalFrom(ctx); principal.Tenant != "" {
+		if tenant != "" && tenant != principal.Tenant {
+			apierror.Abort(ctx, apierror.New(http.StatusForbidden, apierror.CodeTenantForbidden, tenant).WithField("/Tenant"))
+			return
+		}
+		tenant = principal.Tenant
+	}
+	if tenant != "" && !validation.TenantID(tenant) {
+		apierror.Abort(ctx, apierror.New(http.StatusBadRequest, apierror.CodeInvalidTenant, tenant).WithField("/Tenant"))
+		return
+	}
+	roles := []string{auth.RoleUser}
+	if len(body.Roles) > 0 {
+		roles = uniqueRoles(body.Roles)
+	}
+	key, record, err := auth.NewAPIKey(body.Name, roles, tenant)
+	if err != nil {
+		apierror.Abort(ctx, err)
+		return
+	}
+	if err := c.scoped(ctx).CreateAPIKey(&record); err != nil {
+		apierror.Abort(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, APIKeyH{
+		APIKey: CreatedAPIKeyResponse{APIKeyResponse: FromAPIKey(record), Key: key},
+	})
+}
+
+// ListAPIKeys godoc
+// @Summary      List API keys
+// @Description  list every API key, revoked ones included, without the keys themselves. An admin limited to a tenant only sees the keys of that tenant.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  APIKeysH
+// @Failure      401  {object}  apierror.Problem
+// @Failure      403  {object}  apierror.Problem
+// @Failure      429  {object}  apierror.Problem
+// @Failure      500  {object}  apierror.Problem
+// @Failure      504  {object}  apierror.Problem
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /admin/api-keys [get]
+func (c *APIKeyController) ListAPIKeys(ctx *gin.Context) {
+	keys, err := c.scoped(ctx).ListAPIKeys()
+	if err != nil {
+		apierror.Abort(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, APIKeysH{APIKeys: FromAPIKeys(keys)})
+}
+
+// RevokeAPIKey godoc
+// @Summary      Revoke an API key
+// @Description  revoke an API key. Requests with it fail from then on. Revoking a revoked key changes nothing.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        keyID path uint true "ID number of the key to be revoked."
+// @Success      200  {object}  SuccessH
+// @Failure      400  {object}  apierror.Problem
+// @Failure      401  {object}  apierror.Problem
+// @Failure      403  {object}  apierror.Problem
+// @Failure      404  {object}  apierror.Problem
+// @Failure      429  {object}  apierror.Problem
+// @Failure      500  {object}  apierror.Problem
+// @Failure      504  {object}  apierror.Problem
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /admin/api-keys/{keyID} [delete]
+func (c *APIKeyController) RevokeAPIKey(ctx *gin.Context) {
+	keyID, ok := controllers.PathID(ctx, "keyID")
+	if !ok {
+		return
+	}
+	if _, err := c.scoped(ctx).RevokeAPIKey(keyID); err != nil {
+		apierror.Abort(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": fmt.Sprintf("API key %d dicabut.", keyID),
+	})
+}
+
+// scoped returns the store limited to the keys of the tenant of the
+// principal, running in the context of the request.
+func (c *APIKeyController) scoped(ctx *gin.Context) database.OrderStore {
+	return c.store.Scoped(database.Scope{Tenant: auth.PrincipalFrom(ctx).Tenant}).WithContext(ctx.Request.Context())
+}
+
+// uniqueRoles returns roles without duplicates, in the order of auth.Roles.
+func uniqueRoles(roles []string) []string {
+	var unique []string
+	for _, role := range auth.Roles {
+		for _, r := range roles {
+			if r == role {
+				unique = append(unique, role)
+				break
+			}
+		}
+	}
+	return unique
+}
@@ -0,0 +1,39 @@
+// Package v1 serves version 1 of the API under /v1. The handlers, their
+// request and response bodies and the swagger docs of a version live in its
+// own package, sharing the version independent helpers of package
+// controllers, so another version can be served side by side.
+//
+// The swagger docs are generated into docs/v1 by running, in OrderApi:
+//
+//	swag init -g controllers/v1/doc.go -o docs/v1 --instanceName v1
+package v1
+
+// @title           Order API
+// @version         1.0
+// @description     Assignment 2.
+// @description     With tenancy enabled the order routes act on the orders of one tenant, named by the tenant of the credentials,
+// @description     the X-Tenant-ID header or the subdomain, as configured.
+// @description     Requests are rate limited per client IP, per API key or JWT subject, separately for reads and writes, and per tenant.
+// @description     The RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset and RateLimit-Policy headers report the tightest limit,
+// @description     a request over it gets 429 with Retry-After.
+// @description     Every response carries the X-Request-ID of its request, taken from the request or generated, to find its logs.
+// @description     When tracing is enabled, a W3C traceparent header continues the trace of the caller.
+
+// @contact.name   zulkarnaen
+// @contact.email  premiumforspot@gmail.com
+
+// @license.name  Apache 2.0
+// @license.url   http://www.apache.org/licenses/LICENSE-2.0.html
+
+// @host      localhost:8080
+// @BasePath  /v1
+
+// @securityDefinitions.apikey  ApiKeyAuth
+// @in                          header
+// @name                        X-API-Key
+// @description                 API key from POST /admin/api-keys or "orderapi apikey create".
+
+// @securityDefinitions.apikey  BearerAuth
+// @in                          header
+// @name                        Authorization
+// @description                 JWT as "Bearer <token>", with sub, exp and roles claims and an optional tenant claim.
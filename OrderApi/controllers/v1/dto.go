@@ -0,0 +1,161 @@
+package v1
+
+// The JSON bodies of API v1. Requests carry only the fields a client may set
+// and responses only the fields it may see, so server controlled fields such
+// as IDs, OrderID and Version can never be assigned by a client. Every
+// conversion to or from package models is explicit.
+
+import (
+	"time"
+
+	"assignment2.id/orderapi/auth"
+	"assignment2.id/orderapi/database"
+	"assignment2.id/orderapi/models"
+)
+
+// ItemRequest is the body of an item, alone or in an OrderRequest.
+type ItemRequest struct {
+	ItemCode    string `example:"SOMECODE"`
+	Description string `example:"Some description."`
+	Quantity    uint   `example:"1"`
+}
+
+// OrderRequest is the body creating or updating an order. On update an
+// empty CustomerName or OrderedAt keeps the stored value, missing Items keep
+// the stored items and any Items replace them.
+type OrderRequest struct {
+	CustomerName string `example:"Test"`
+	Items        []ItemRequest
+	OrderedAt    time.Time `example:"2019-11-09T21:21:46+00:00"`
+}
+
+type ItemResponse struct {
+	ID          uint   `example:"1"`
+	ItemCode    string `example:"SOMECODE"`
+	Description string `example:"Some description."`
+	Quantity    uint   `example:"1"`
+	OrderID     uint   `example:"1"`
+}
+
+type OrderResponse struct {
+	ID           uint   `example:"1"`
+	CustomerName string `example:"Test"`
+	Items        []ItemResponse
+	OrderedAt    time.Time `example:"2019-11-09T21:21:46+00:00"`
+	Version      uint      `example:"1"`
+	// Owner is the subject of the principal that created the order.
+	Owner string `example:"alice"`
+	// Tenant is the business unit the order belongs to, empty with tenancy
+	// disabled.
+	Tenant string `example:"acme"`
+}
+
+// Model returns the item r describes, without an ID or order.
+func (r ItemRequest) Model() models.Item {
+	return models.Item{
+		ItemCode:    r.ItemCode,
+		Description: r.Description,
+		Quantity:    r.Quantity,
+	}
+}
+
+// Model returns the order r describes, without an ID. Items stay nil when
+// r has none so an update can tell them from an empty list.
+func (r OrderRequest) Model() models.Order {
+	order := models.Order{
+		CustomerName: r.CustomerName,
+		OrderedAt:    r.OrderedAt,
+	}
+	if r.Items != nil {
+		order.Items = make([]models.Item, len(r.Items))
+		for i, item := range r.Items {
+			order.Items[i] = item.Model()
+		}
+	}
+	return order
+}
+
+func FromItem(item models.Item) ItemResponse {
+	return ItemResponse{
+		ID:          item.ID,
+		ItemCode:    item.ItemCode,
+		Description: item.Description,
+		Quantity:    item.Quantity,
+		OrderID:     item.OrderID,
+	}
+}
+
+func FromItems(items []models.Item) []ItemResponse {
+	responses := make([]ItemResponse, len(items))
+	for i, item := range items {
+		responses[i] = FromItem(item)
+	}
+	return responses
+}
+
+func FromOrder(order models.Order) OrderResponse {
+	return OrderResponse{
+		ID:           order.ID,
+		CustomerName: order.CustomerName,
+		Items:        FromItems(order.Items),
+		OrderedAt:    order.OrderedAt,
+		Version:      order.Version,
+		Owner:        order.Owner,
+		Tenant:       order.TenantID,
+	}
+}
+
+func FromOrders(orders []models.Order) []OrderResponse {
+	responses := make([]OrderResponse, len(orders))
+	for i, order := range orders {
+		responses[i] = FromOrder(order)
+	}
+	return responses
+}
+
+// APIKeyRequest is the body creating an API key. A key without Roles gets
+// the user role. A key with a Tenant only reaches the orders of that tenant.
+// An admin limited to a tenant only creates keys of that tenant.
+type APIKeyRequest struct {
+	Name   string   `validate:"required,max=255" example:"billing-service"`
+	Roles  []string `validate:"dive,oneof=user support admin" example:"user"`
+	Tenant string   `example:"acme"`
+}
+
+type APIKeyResponse struct {
+	ID   uint   `example:"1"`
+	Name string `example:"billing-service"`
+	// Prefix is the start of the key, to tell keys apart.
+	Prefix string   `example:"oak_Zm9vYmFy"`
+	Roles  []string `example:"user"`
+	// Tenant is the tenant the key is limited to, empty for every tenant.
+	Tenant    string     `example:"acme"`
+	CreatedAt time.Time  `example:"2019-11-09T21:21:46+00:00"`
+	RevokedAt *time.Time `example:"2019-11-10T21:21:46+00:00"`
+}
+
+// CreatedAPIKeyResponse is the only response showing the key itself.
+type CreatedAPIKeyResponse struct {
+	APIKeyResponse
+	Key string `example:"oak_Zm9vYmFyYmF6cXV4cXV1eGNvcmdlZ3JhdWx0Z2FycGx5"`
+}
+
+func FromAPIKey(key database.APIKey) APIKeyResponse {
+	return APIKeyResponse{
+		ID:        key.ID,
+		Name:      key.Name,
+		Prefix:    key.Prefix,
+		Roles:     auth.SplitRoles(key.Roles),
+		Tenant:    key.TenantID,
+		CreatedAt: key.CreatedAt,
+		RevokedAt: key.RevokedAt,
+	}
+}
+
+func FromAPIKeys(keys []database.APIKey) []APIKeyResponse {
+	responses := make([]APIKeyResponse, len(keys))
+	for i, key := range keys {
+		responses[i] = FromAPIKey(key)
+	}
+	return responses
+}
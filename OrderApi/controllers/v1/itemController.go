@@ -0,0 +1,338 @@
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"assignment2.id/orderapi/apierror"
+	"assignment2.id/orderapi/controllers"
+	"assignment2.id/orderapi/models"
+	"assignment2.id/orderapi/policy"
+	"assignment2.id/orderapi/validation"
+	"github.com/gin-gonic/gin"
+)
+
+// parseItemPath reads the orderID and, when withItem is set, itemID path
+// parameters, aborting with 400 when one is not a number.
+func parseItemPath(ctx *gin.Context, withItem bool) (orderID, itemID uint, ok bool) {
+	if orderID, ok = controllers.PathID(ctx, "orderID"); !ok || !withItem {
+		return orderID, 0, ok
+	}
+	itemID, ok = controllers.PathID(ctx, "itemID")
+	return orderID, itemID, ok
+}
+
+// GetItems godoc
+// @Summary      List the items of an order
+// @Tags         items
+// @Accept       json
+// @Produce      json
+// @Param        orderID path uint true "ID number of the order"
+// @Success      200  {object}  ItemsH
+// @Failure      400  {object}  apierror.Problem
+// @Failure      401  {object}  apierror.Problem
+// @Failure      403  {object}  apierror.Problem
+// @Failure      404  {object}  apierror.Problem
+// @Failure      429  {object}  apierror.Problem
+// @Failure      500  {object}  apierror.Problem
+// @Failure      504  {object}  apierror.Problem
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /orders/{orderID}/items [get]
+func (c *OrderController) GetItems(ctx *gin.Context) {
+	store, ok := controllers.Authorize(ctx, c.store, policy.ReadOrder)
+	if !ok {
+		return
+	}
+	orderID, _, ok := parseItemPath(ctx, false)
+	if !ok {
+		return
+	}
+	items, err := store.GetItems(orderID)
+	if err != nil {
+		apierror.Abort(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"items": FromItems(items),
+	})
+}
+
+// GetItem godoc
+// @Summary      Get an item of an order
+// @Tags         items
+// @Accept       json
+// @Produce      json
+// @Param        orderID path uint true "ID number of the order"
+// @Param        itemID  path uint true "ID number of the item"
+// @Success      200  {object}  ItemH
+// @Failure      400  {object}  apierror.Problem
+// @Failure      401  {object}  apierror.Problem
+// @Failure      403  {object}  apierror.Problem
+// @Failure      404  {object}  apierror.Problem
+// @Failure      429  {object}  apierror.Problem
+// @Failure      500  {object}  apierror.Problem
+// @Failure      504  {object}  apierror.Problem
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /orders/{orderID}/items/{itemID} [get]
+func (c *OrderController) GetItem(ctx *gin.Context) {
+	store, ok := controllers.Authorize(ctx, c.store, policy.ReadOrder)
+	if !ok {
+		return
+	}
+	orderID, itemID, ok := parseItemPath(ctx, true)
+	if !ok {
+		return
+	}
+	item, err := store.GetItem(orderID, itemID)
+	if err != nil {
+		apierror.Abort(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"item": FromItem(item),
+	})
+}
+
+// CreateItem godoc
+// @Summary      Add an item to an order
+// @Tags         items
+// @Accept       json
+// @Produce      json
+// @Param        orderID path uint true "ID number of the order"
+// @Param        item body ItemRequest true "JSON of the item to be added."
+// @Param        If-Match header string true "ETag of the order from GetOrder, or *."
+// @Success      201  {object}  ItemH
+// @Header       201  {string}  ETag  "ETag of the order after the write."
+// @Failure      400  {object}  apierror.Problem
+// @Failure      401  {object}  apierror.Problem
+// @Failure      403  {object}  apierror.Problem
+// @Failure      404  {object}  apierror.Problem
+// @Failure      412  {object}  apierror.Problem
+// @Failure      422  {object}  apierror.Problem
+// @Failure      428  {object}  apierror.Problem
+// @Failure      429  {object}  apierror.Problem
+// @Failure      500  {object}  apierror.Problem
+// @Failure      504  {object}  apierror.Problem
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /orders/{orderID}/items [post]
+func (c *OrderController) CreateItem(ctx *gin.Context) {
+	store, ok := controllers.Authorize(ctx, c.store, policy.UpdateOrder)
+	if !ok {
+		return
+	}
+	orderID, _, ok := parseItemPath(ctx, false)
+	if !ok {
+		return
+	}
+	version, ok := controllers.RequireIfMatch(ctx, store, orderID)
+	if !ok {
+		return
+	}
+	var body ItemRequest
+	if err := controllers.BindStrict(ctx, &body); err != nil {
+		apierror.Abort(ctx, apierror.InvalidBody(err))
+		return
+	}
+	item := body.Model()
+	if err := validation.Item(&item); err != nil {
+		apierror.Abort(ctx, err)
+		return
+	}
+	newVersion, err := store.CreateItem(orderID, &item, version)
+	if err != nil {
+		apierror.Abort(ctx, err)
+		return
+	}
+	ctx.Header("ETag", controllers.ETag(newVersion))
+	ctx.JSON(http.StatusCreated, gin.H{
+		"item": FromItem(item),
+	})
+}
+
+// UpdateItem godoc
+// @Summary      Replace an item of an order
+// @Tags         items
+// @Accept       json
+// @Produce      json
+// @Param        orderID path uint true "ID number of the order"
+// @Param        itemID  path uint true "ID number of the item"
+// @Param        item body ItemRequest true "JSON of the item."
+// @Param        If-Match header string true "ETag of the order from GetOrder, or *."
+// @Success      200  {object}  ItemH
+// @Header       200  {string}  ETag  "ETag of the order after the write."
+// @Failure      400  {object}  apierror.Problem
+// @Failure      401  {object}  apierror.Problem
+// @Failure      403  {object}  apierror.Problem
+// @Failure      404  {object}  apierror.Problem
+// @Failure      412  {object}  apierror.Problem
+// @Failure      422  {object}  apierror.Problem
+// @Failure      428  {object}  apierror.Problem
+// @Failure      429  {object}  apierror.Problem
+// @Failure      500  {object}  apierror.Problem
+// @Failure      504  {object}  apierror.Problem
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /orders/{orderID}/items/{itemID} [put]
+func (c *OrderController) UpdateItem(ctx *gin.Context) {
+	store, ok := controllers.Authorize(ctx, c.store, policy.UpdateOrder)
+	if !ok {
+		return
+	}
+	orderID, itemID, ok := parseItemPath(ctx, true)
+	if !ok {
+		return
+	}
+	version, ok := controllers.RequireIfMatch(ctx, store, orderID)
+	if !ok {
+		return
+	}
+	var body ItemRequest
+	if err := controllers.BindStrict(ctx, &body); err != nil {
+		apierror.Abort(ctx, apierror.InvalidBody(err))
+		return
+	}
+	item, newVersion, err := store.UpdateItem(orderID, itemID, version, func(item *models.Item) error {
+		item.ItemCode = body.ItemCode
+		item.Description = body.Description
+		item.Quantity = body.Quantity
+		return validation.Item(item)
+	})
+	if err != nil {
+		apierror.Abort(ctx, err)
+		return
+	}
+	ctx.Header("ETag", controllers.ETag(newVersion))
+	ctx.JSON(http.StatusOK, gin.H{
+		"item": FromItem(item),
+	})
+}
+
+// PatchItem godoc
+// @Summary      Patch an item of an order
+// @Description  patch an item with a JSON Merge Patch (application/merge-patch+json) or a JSON Patch
+// @Description  (application/json-patch+json) against {"ItemCode": "...", "Description": "...", "Quantity": 1}.
+// @Tags         items
+// @Accept       json
+// @Produce      json
+// @Param        orderID path uint true "ID number of the order"
+// @Param        itemID  path uint true "ID number of the item"
+// @Param        patch body object true "JSON Merge Patch or JSON Patch document."
+// @Param        If-Match header string true "ETag of the order from GetOrder, or *."
+// @Success      200  {object}  ItemH
+// @Header       200  {string}  ETag  "ETag of the order after the write."
+// @Failure      400  {object}  apierror.Problem
+// @Failure      401  {object}  apierror.Problem
+// @Failure      403  {object}  apierror.Problem
+// @Failure      404  {object}  apierror.Problem
+// @Failure      409  {object}  apierror.Problem
+// @Failure      412  {object}  apierror.Problem
+// @Failure      415  {object}  apierror.Problem
+// @Failure      422  {object}  apierror.Problem
+// @Failure      428  {object}  apierror.Problem
+// @Failure      429  {object}  apierror.Problem
+// @Failure      500  {object}  apierror.Problem
+// @Failure      504  {object}  apierror.Problem
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /orders/{orderID}/items/{itemID} [patch]
+func (c *OrderController) PatchItem(ctx *gin.Context) {
+	store, ok := controllers.Authorize(ctx, c.store, policy.UpdateOrder)
+	if !ok {
+		return
+	}
+	orderID, itemID, ok := parseItemPath(ctx, true)
+	if !ok {
+		return
+	}
+	version, ok := controllers.RequireIfMatch(ctx, store, orderID)
+	if !ok {
+		return
+	}
+	apply, ok := controllers.ReadPatch(ctx)
+	if !ok {
+		return
+	}
+	item, newVersion, err := store.UpdateItem(orderID, itemID, version, func(item *models.Item) error {
+		doc, err := json.Marshal(patchItem{
+			ItemCode:    item.ItemCode,
+			Description: item.Description,
+			Quantity:    item.Quantity,
+		})
+		if err != nil {
+			return err
+		}
+		patched, err := apply(doc)
+		if err != nil {
+			return controllers.PatchError(err)
+		}
+		var result patchItem
+		if err := controllers.DecodeStrict(patched, &result); err != nil {
+			return controllers.PatchError(err)
+		}
+		if result.ID != 0 {
+			return controllers.ErrIDImmutable
+		}
+		item.ItemCode = result.ItemCode
+		item.Description = result.Description
+		item.Quantity = result.Quantity
+		return validation.Item(item)
+	})
+	if err != nil {
+		apierror.Abort(ctx, err)
+		return
+	}
+	ctx.Header("ETag", controllers.ETag(newVersion))
+	ctx.JSON(http.StatusOK, gin.H{
+		"item": FromItem(item),
+	})
+}
+
+// DeleteItem godoc
+// @Summary      Delete an item of an order
+// @Tags         items
+// @Accept       json
+// @Produce      json
+// @Param        orderID path uint true "ID number of the order"
+// @Param        itemID  path uint true "ID number of the item"
+// @Param        If-Match header string true "ETag of the order from GetOrder, or *."
+// @Success      200  {object}  SuccessH
+// @Header       200  {string}  ETag  "ETag of the order after the write."
+// @Failure      400  {object}  apierror.Problem
+// @Failure      401  {object}  apierror.Problem
+// @Failure      403  {object}  apierror.Problem
+// @Failure      404  {object}  apierror.Problem
+// @Failure      412  {object}  apierror.Problem
+// @Failure      428  {object}  apierror.Problem
+// @Failure      429  {object}  apierror.Problem
+// @Failure      500  {object}  apierror.Problem
+// @Failure      504  {object}  apierror.Problem
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /orders/{orderID}/items/{itemID} [delete]
+func (c *OrderController) DeleteItem(ctx *gin.Context) {
+	store, ok := controllers.Authorize(ctx, c.store, policy.UpdateOrder)
+	if !ok {
+		return
+	}
+	orderID, itemID, ok := parseItemPath(ctx, true)
+	if !ok {
+		return
+	}
+	version, ok := controllers.RequireIfMatch(ctx, store, orderID)
+	if !ok {
+		return
+	}
+	newVersion, err := store.DeleteItem(orderID, itemID, version)
+	if err != nil {
+		apierror.Abort(ctx, err)
+		return
+	}
+	ctx.Header("ETag", controllers.ETag(newVersion))
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": fmt.Sprintf("item %d terhapus.", itemID),
+	})
+}
@@ -0,0 +1,116 @@
+package v1
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"assignment2.id/orderapi/auth"
+	"assignment2.id/orderapi/controllers"
+	"assignment2.id/orderapi/database"
+	"assignment2.id/orderapi/models"
+	"github.com/gin-gonic/gin"
+)
+
+func TestItemWritesReturnETag(t *testing.T) {
+	store := database.NewMemoryStore()
+	order := models.Order{CustomerName: "A", Items: []models.Item{{ItemCode: "X", Quantity: 1}}}
+	if err := store.CreateOrder(&order); err != nil {
+		t.Fatal(err)
+	}
+	router := newTestRouter(store)
+	steps := []struct {
+		name        string
+		method      string
+		path        string
+		contentType string
+		body        string
+		ifMatch     string
+		status      int
+		etag        string
+	}{
+		{"create", http.MethodPost, "/orders/1/items", "application/json", `{"ItemCode": "Y", "Quantity": 1}`, `"1"`, http.StatusCreated, `"2"`},
+		{"update", http.MethodPut, "/orders/1/items/2", "application/json", `{"ItemCode": "Y", "Quantity": 2}`, `"2"`, http.StatusOK, `"3"`},
+		{"patch at any version", http.MethodPatch, "/orders/1/items/2", controllers.MergePatchType, `{"Quantity": 3}`, "*", http.StatusOK, `"4"`},
+		{"stale update", http.MethodPut, "/orders/1/items/2", "application/json", `{"ItemCode": "Y", "Quantity": 4}`, `"3"`, http.StatusPreconditionFailed, ""},
+		{"delete", http.MethodDelete, "/orders/1/items/2", "", "", `"4"`, http.StatusOK, `"5"`},
+	}
+	for _, step := range steps {
+		w := serve(router, step.method, step.path, step.body, http.Header{
+			"Content-Type": {step.contentType},
+			"If-Match":     {step.ifMatch},
+		})
+		if w.Code != step.status {
+			t.Fatalf("%s: got status %d, want %d: %s", step.name, w.Code, step.status, w.Body)
+		}
+		if got := w.Header().Get("ETag"); got != step.etag {
+			t.Errorf("%s: got ETag %q, want %q", step.name, got, step.etag)
+		}
+	}
+}
+
+func TestIdempotentReplayKeepsETag(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(controllers.ErrorHandler(), auth.Anonymous())
+	NewOrderController(database.NewMemoryStore(), time.Hour, 0).Register(router)
+
+	header := http.Header{
+		"Content-Type":                   {"application/json"},
+		controllers.IdempotencyKeyHeader: {"k1"},
+	}
+	body := `{"CustomerName": "A", "Items": [{"ItemCode": "X", "Quantity": 1}]}`
+	first := serve(router, http.MethodPost, "/orders", body, header)
+	if first.Code != http.StatusCreated {
+		t.Fatalf("got status %d, want 201: %s", first.Code, first.Body)
+	}
+	replayed := serve(router, http.MethodPost, "/orders", body, header)
+	if replayed.Code != http.StatusCreated || replayed.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("got status %d, Idempotent-Replayed %q, want a replayed 201", replayed.Code, replayed.Header().Get("Idempotent-Replayed"))
+	}
+	if got, want := replayed.Header().Get("ETag"), first.Header().Get("ETag"); got != want || got == "" {
+		t.Errorf("got replayed ETag %q, want %q", got, want)
+	}
+}
+
+func TestPatchItem(t *testing.T) {
+	tests := []struct {
+		name        string
+		patch       string
+		status      int
+		code        string
+		description string
+		quantity    uint
+	}{
+		{"missing keys are kept", `{"Quantity": 2}`, http.StatusOK, "X", "first", 2},
+		{"null clears the description", `{"Description": null}`, http.StatusOK, "X", "", 1},
+		{"null item code is required", `{"ItemCode": null}`, http.StatusUnprocessableEntity, "", "", 0},
+		{"unknown key", `{"Price": 1}`, http.StatusUnprocessableEntity, "", "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := database.NewMemoryStore()
+			order := models.Order{CustomerName: "A", Items: []models.Item{{ItemCode: "X", Description: "first", Quantity: 1}}}
+			if err := store.CreateOrder(&order); err != nil {
+				t.Fatal(err)
+			}
+			w := serve(newTestRouter(store), http.MethodPatch, "/orders/1/items/1", tt.patch, http.Header{
+				"Content-Type": {controllers.MergePatchType},
+				"If-Match":     {"*"},
+			})
+			if w.Code != tt.status {
+				t.Fatalf("got status %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+			item, err := store.GetItem(1, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if item.ItemCode != tt.code || item.Description != tt.description || item.Quantity != tt.quantity {
+				t.Errorf("got item %q %q %d, want %q %q %d", item.ItemCode, item.Description, item.Quantity, tt.code, tt.description, tt.quantity)
+			}
+		})
+	}
+}
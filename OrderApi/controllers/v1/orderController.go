@@ -0,0 +1,523 @@
+package v1
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"assignment2.id/orderapi/apierror"
+	"assignment2.id/orderapi/auth"
+	"assignment2.id/orderapi/controllers"
+	"assignment2.id/orderapi/database"
+	"assignment2.id/orderapi/models"
+	"assignment2.id/orderapi/policy"
+	"assignment2.id/orderapi/tenancy"
+	"assignment2.id/orderapi/tracing"
+	"assignment2.id/orderapi/validation"
+	"github.com/gin-gonic/gin"
+)
+
+// OrderController serves the /orders routes of v1 from the OrderStore it is
+// given.
+type OrderController struct {
+	store       database.OrderStore
+	idempotency controllers.Idempotency
+}
+
+// NewOrderController returns a controller replaying the response of
+// CreateOrder to an Idempotency-Key for idempotencyTTL, 0 ignores the header,
+// and letting each tenant create up to maxOrders orders, 0 is unlimited.
+func NewOrderController(store database.OrderStore, idempotencyTTL time.Duration, maxOrders int) *OrderController {
+	return &OrderController{
+		store: store,
+		idempotency: controllers.Idempotency{
+			TTL:   idempotencyTTL,
+			Quota: controllers.Quota{MaxOrders: maxOrders},
+		},
+	}
+}
+
+// Register adds the routes of the controller to router, each handler
+// recording a span.
+func (c *OrderController) Register(router gin.IRouter) {
+	router = router.Group("", tracing.Handler())
+	router.POST("/orders", c.CreateOrder)
+	router.GET("/orders", c.ListOrders)
+	router.GET("/orders/:orderID", c.GetOrder)
+	router.PUT("/orders/:orderID", c.UpdateOrder)
+	router.PATCH("/orders/:orderID", c.PatchOrder)
+	router.DELETE("/orders/:orderID", c.DeleteOrder)
+	router.POST("/orders/:orderID/restore", c.RestoreOrder)
+
+	router.GET("/orders/:orderID/items", c.GetItems)
+	router.POST("/orders/:orderID/items", c.CreateItem)
+	router.GET("/orders/:orderID/items/:itemID", c.GetItem)
+	router.PUT("/orders/:orderID/items/:itemID", c.UpdateItem)
+	router.PATCH("/orders/:orderID/items/:itemID", c.PatchItem)
+	router.DELETE("/orders/:orderID/items/:itemID", c.DeleteItem)
+}
+
+// DeleteOrder godoc
+// @Summary      Delete an order
+// @Description  delete order by ID including its items. Deleted orders can be restored until they are purged.
+// @Description  With purge=true the order is deleted permanently, whether it was deleted before or not.
+// @Description  Needs the admin role.
+// @Tags         orders
+// @Accept       json
+// @Produce      json
+// @Param        orderID path uint true "ID number of the order to be deleted."
+// @Param        purge query bool false "Delete permanently."
+// @Param        If-Match header string true "ETag of the order from GetOrder, or *."
+// @Success      200  {object}  SuccessH
+// @Failure      400  {object}  apierror.Problem
+// @Failure      401  {object}  apierror.Problem
+// @Failure      403  {object}  apierror.Problem
+// @Failure      404  {object}  apierror.Problem
+// @Failure      412  {object}  apierror.Problem
+// @Failure      428  {object}  apierror.Problem
+// @Failure      429  {object}  apierror.Problem
+// @Failure      500  {object}  apierror.Problem
+// @Failure      504  {object}  apierror.Problem
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /orders/{orderID} [delete]
+func (c *OrderController) DeleteOrder(ctx *gin.Context) {
+	store, ok := controllers.Authorize(ctx, c.store, policy.DeleteOrder)
+	if !ok {
+		return
+	}
+	parsedID, ok := controllers.PathID(ctx, "orderID")
+	if !ok {
+		return
+	}
+	purge, err := strconv.ParseBool(ctx.DefaultQuery("purge", "false"))
+	if err != nil {
+		apierror.Abort(ctx, apierror.New(http.StatusBadRequest, apierror.CodeInvalidPurge).WithField("purge"))
+		return
+	}
+	// A purge is a delete the principal must also be allowed to purge.
+	if purge {
+		if store, ok = controllers.Authorize(ctx, c.store, policy.PurgeOrder); !ok {
+			return
+		}
+	}
+	version, ok := controllers.RequireIfMatch(ctx, store, parsedID)
+	if !ok {
+		return
+	}
+	message := "id %d terhapus."
+	if purge {
+		err = store.PurgeOrderById(parsedID, version)
+		message = "id %d terhapus permanen."
+	} else {
+		err = store.DeleteOrderById(parsedID, version)
+	}
+	if err != nil {
+		apierror.Abort(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": fmt.Sprintf(message, parsedID),
+	})
+}
+
+// RestoreOrder godoc
+// @Summary      Restore an order
+// @Description  restore a deleted order including its items. Needs the admin role.
+// @Tags         orders
+// @Accept       json
+// @Produce      json
+// @Param        orderID path uint true "ID number of the order to be restored."
+// @Success      200  {object}  SuccessH
+// @Failure      400  {object}  apierror.Problem
+// @Failure      401  {object}  apierror.Problem
+// @Failure      403  {object}  apierror.Problem
+// @Failure      404  {object}  apierror.Problem
+// @Failure      409  {object}  apierror.Problem
+// @Failure      429  {object}  apierror.Problem
+// @Failure      500  {object}  apierror.Problem
+// @Failure      504  {object}  apierror.Problem
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /orders/{orderID}/restore [post]
+func (c *OrderController) RestoreOrder(ctx *gin.Context) {
+	store, ok := controllers.Authorize(ctx, c.store, policy.RestoreOrder)
+	if !ok {
+		return
+	}
+	parsedID, ok := controllers.PathID(ctx, "orderID")
+	if !ok {
+		return
+	}
+	if err := store.RestoreOrderById(parsedID); err != nil {
+		apierror.Abort(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": fmt.Sprintf("id %d dipulihkan.", parsedID),
+	})
+}
+
+// UpdateOrder godoc
+// @Summary      Update an order
+// @Description  update order by ID including its items. Previous items are discarded.
+// @Tags         orders
+// @Accept       json
+// @Produce      json
+// @Param        orderID path uint true "ID number of the order to be updated."
+// @Param        order body OrderRequest true "JSON of the order to be updated."
+// @Param        If-Match header string true "ETag of the order from GetOrder, or *."
+// @Success      200  {object}  SuccessH
+// @Header       200  {string}  ETag  "ETag of the updated order."
+// @Failure      400  {object}  apierror.Problem
+// @Failure      401  {object}  apierror.Problem
+// @Failure      403  {object}  apierror.Problem
+// @Failure      404  {object}  apierror.Problem
+// @Failure      412  {object}  apierror.Problem
+// @Failure      422  {object}  apierror.Problem
+// @Failure      428  {object}  apierror.Problem
+// @Failure      429  {object}  apierror.Problem
+// @Failure      500  {object}  apierror.Problem
+// @Failure      504  {object}  apierror.Problem
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /orders/{orderID} [put]
+func (c *OrderController) UpdateOrder(ctx *gin.Context) {
+	store, ok := controllers.Authorize(ctx, c.store, policy.UpdateOrder)
+	if !ok {
+		return
+	}
+	parsedID, ok := controllers.PathID(ctx, "orderID")
+	if !ok {
+		return
+	}
+	version, ok := controllers.RequireIfMatch(ctx, store, parsedID)
+	if !ok {
+		return
+	}
+	var body OrderRequest
+	if err := controllers.BindStrict(ctx, &body); err != nil {
+		apierror.Abort(ctx, apierror.InvalidBody(err))
+		return
+	}
+	updatedOrder := body.Model()
+	if err := validation.Order(&updatedOrder, true); err != nil {
+		apierror.Abort(ctx, err)
+		return
+	}
+	if err := store.UpdateOrderById(parsedID, &updatedOrder, version); err != nil {
+		apierror.Abort(ctx, err)
+		return
+	}
+	ctx.Header("ETag", controllers.ETag(updatedOrder.Version))
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": fmt.Sprintf("id %d terupdate.", parsedID),
+	})
+}
+
+// CreateOrder godoc
+// @Summary      Create an order
+// @Description  Create an order including its items, if provided.
+// @Description  A request retried with the same Idempotency-Key gets the original response instead of creating another order.
+// @Description  The order is owned by the caller and belongs to the tenant of the request.
+// @Description  A tenant with as many orders as its quota gets 403.
+// @Tags         orders
+// @Accept       json
+// @Produce      json
+// @Param        order body OrderRequest true "JSON of the order to be made."
+// @Param        Idempotency-Key header string false "Unique key of the request, at most 255 characters."
+// @Success      201  {object}  OrderH
+// @Header       201  {string}  Idempotent-Replayed  "true when the response is replayed."
+// @Failure      400  {object}  apierror.Problem
+// @Failure      401  {object}  apierror.Problem
+// @Failure      403  {object}  apierror.Problem
+// @Failure      409  {object}  apierror.Problem
+// @Failure      422  {object}  apierror.Problem
+// @Failure      429  {object}  apierror.Problem
+// @Failure      500  {object}  apierror.Problem
+// @Failure      504  {object}  apierror.Problem
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /orders [post]
+func (c *OrderController) CreateOrder(ctx *gin.Context) {
+	store, ok := controllers.Authorize(ctx, c.store, policy.CreateOrder)
+	if !ok {
+		return
+	}
+	raw, err := io.ReadAll(ctx.Request.Body)
+	if err != nil {
+		apierror.Abort(ctx, apierror.InvalidBody(err))
+		return
+	}
+	var body OrderRequest
+	if err := controllers.DecodeStrict(raw, &body); err != nil {
+		apierror.Abort(ctx, apierror.InvalidBody(err))
+		return
+	}
+	newOrder := body.Model()
+	newOrder.Owner = auth.PrincipalFrom(ctx).Subject
+	newOrder.TenantID = tenancy.From(ctx)
+	if err := validation.Order(&newOrder, false); err != nil {
+		apierror.Abort(ctx, err)
+		return
+	}
+	c.idempotency.CreateOrder(ctx, store, "v1", raw, &newOrder, func(order *models.Order) interface{} {
+		return gin.H{"order": FromOrder(*order)}
+	})
+}
+
+// GetOrder godoc
+// @Summary      Get an order
+// @Description  get order by ID
+// @Tags         orders
+// @Accept       json
+// @Produce      json
+// @Param        orderID path uint true "ID number of the order"
+// @Param        If-None-Match header string false "ETag of a cached copy of the order."
+// @Success      200  {object}  OrderH
+// @Header       200  {string}  ETag  "Pass it in If-Match to modify the order."
+// @Success      304  {object}  nil
+// @Failure      400  {object}  apierror.Problem
+// @Failure      401  {object}  apierror.Problem
+// @Failure      403  {object}  apierror.Problem
+// @Failure      404  {object}  apierror.Problem
+// @Failure      429  {object}  apierror.Problem
+// @Failure      500  {object}  apierror.Problem
+// @Failure      504  {object}  apierror.Problem
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /orders/{orderID} [get]
+func (c *OrderController) GetOrder(ctx *gin.Context) {
+	store, ok := controllers.Authorize(ctx, c.store, policy.ReadOrder)
+	if !ok {
+		return
+	}
+	parsedID, ok := controllers.PathID(ctx, "orderID")
+	if !ok {
+		return
+	}
+	orderData, err := store.GetOrderById(parsedID)
+	if err != nil {
+		apierror.Abort(ctx, err)
+		return
+	}
+	ctx.Header("ETag", controllers.ETag(orderData.Version))
+	if controllers.NotModified(ctx, orderData.Version) {
+		ctx.Status(http.StatusNotModified)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"order": FromOrder(orderData),
+	})
+}
+
+// ListOrders godoc
+// @Summary      List orders
+// @Description  list orders with their items. Pagination is cursor based unless offset is given.
+// @Description  Users only see the orders they own, support staff and admins see every order.
+// @Description  When ids is given the orders with those IDs are returned instead and the other parameters are ignored.
+// @Description  The response is then {"orders": [...], "missing": [IDs without an order]}.
+// @Tags         orders
+// @Accept       json
+// @Produce      json
+// @Param        ids                  query  []uint  false  "Fetch these order IDs (at most 100)." collectionFormat(csv)
+// @Param        limit                query  int     false  "Maximum number of orders in a page (1-100)." default(20)
+// @Param        offset               query  int     false  "Number of orders to skip, selects offset pagination."
+// @Param        cursor               query  string  false  "Cursor from the next or prev link of a previous page."
+// @Param        sort                 query  string  false  "Column to sort by, prefix with - for descending order." default(id)
+// @Param        customer_name        query  string  false  "Exact customer name."
+// @Param        customer_name_prefix query  string  false  "Customer name prefix."
+// @Param        ordered_from         query  string  false  "Earliest OrderedAt (RFC 3339), inclusive."
+// @Param        ordered_to           query  string  false  "Latest OrderedAt (RFC 3339), inclusive."
+// @Param        item_code            query  []string false "Only orders having an item with one of these codes." collectionFormat(csv)
+// @Success      200  {object}  OrderListH
+// @Failure      400  {object}  apierror.Problem
+// @Failure      401  {object}  apierror.Problem
+// @Failure      403  {object}  apierror.Problem
+// @Failure      429  {object}  apierror.Problem
+// @Failure      500  {object}  apierror.Problem
+// @Failure      504  {object}  apierror.Problem
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /orders [get]
+func (c *OrderController) ListOrders(ctx *gin.Context) {
+	store, ok := controllers.Authorize(ctx, c.store, policy.ReadOrder)
+	if !ok {
+		return
+	}
+	if _, ok := ctx.GetQuery("ids"); ok {
+		c.getOrdersByIds(ctx, store)
+		return
+	}
+	query := database.OrderListQuery{Limit: defaultListLimit}
+	if limit := ctx.Query("limit"); limit != "" {
+		parsed, err := strconv.Atoi(limit)
+		if err != nil || parsed < 1 || parsed > maxListLimit {
+			apierror.Abort(ctx, apierror.New(http.StatusBadRequest, apierror.CodeInvalidLimit, maxListLimit).WithField("limit"))
+			return
+		}
+		query.Limit = parsed
+	}
+	_, offsetMode := ctx.GetQuery("offset")
+	if offsetMode {
+		parsed, err := strconv.Atoi(ctx.Query("offset"))
+		if err != nil || parsed < 0 {
+			apierror.Abort(ctx, apierror.New(http.StatusBadRequest, apierror.CodeInvalidOffset).WithField("offset"))
+			return
+		}
+		query.Offset = parsed
+	}
+	if cursor := ctx.Query("cursor"); cursor != "" {
+		if offsetMode {
+			apierror.Abort(ctx, apierror.New(http.StatusBadRequest, apierror.CodeCursorWithOffset).WithField("cursor"))
+			return
+		}
+		decoded, err := database.DecodeCursor(cursor)
+		if err != nil {
+			apierror.Abort(ctx, err)
+			return
+		}
+		query.Cursor = decoded
+	}
+	sort := ctx.DefaultQuery("sort", "id")
+	if strings.HasPrefix(sort, "-") {
+		query.SortDesc = true
+		sort = sort[1:]
+	}
+	column, err := database.SortColumn(sort)
+	if err != nil {
+		apierror.Abort(ctx, err)
+		return
+	}
+	query.SortBy = column
+	query.CustomerName = ctx.Query("customer_name")
+	query.CustomerNamePrefix = ctx.Query("customer_name_prefix")
+	for param, target := range map[string]**time.Time{
+		"ordered_from": &query.OrderedFrom,
+		"ordered_to":   &query.OrderedTo,
+	} {
+		if value := ctx.Query(param); value != "" {
+			parsed, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				apierror.Abort(ctx, apierror.New(http.StatusBadRequest, apierror.CodeInvalidTime, param).WithField(param))
+				return
+			}
+			*target = &parsed
+		}
+	}
+	for _, codes := range ctx.QueryArray("item_code") {
+		for _, code := range strings.Split(codes, ",") {
+			if code != "" {
+				query.ItemCodes = append(query.ItemCodes, code)
+			}
+		}
+	}
+
+	page, err := store.ListOrders(query)
+	if err != nil {
+		apierror.Abort(ctx, err)
+		return
+	}
+	result := OrderListH{Orders: FromOrders(page.Orders), Total: page.Total, Limit: query.Limit}
+	link := func(set map[string]string) *string {
+		values := ctx.Request.URL.Query()
+		values.Del("cursor")
+		values.Del("offset")
+		for k, v := range set {
+			values.Set(k, v)
+		}
+		u := *ctx.Request.URL
+		u.RawQuery = values.Encode()
+		s := u.String()
+		return &s
+	}
+	if offsetMode {
+		if page.HasNext {
+			result.Next = link(map[string]string{"offset": strconv.Itoa(query.Offset + query.Limit)})
+		}
+		if page.HasPrev {
+			prev := query.Offset - query.Limit
+			if prev < 0 {
+				prev = 0
+			}
+			result.Prev = link(map[string]string{"offset": strconv.Itoa(prev)})
+		}
+	} else if len(page.Orders) > 0 {
+		if page.HasNext {
+			last := page.Orders[len(page.Orders)-1]
+			result.Next = link(map[string]string{"cursor": database.EncodeCursor(database.CursorFor(last, query.SortBy, false))})
+		}
+		if page.HasPrev {
+			first := page.Orders[0]
+			result.Prev = link(map[string]string{"cursor": database.EncodeCursor(database.CursorFor(first, query.SortBy, true))})
+		}
+	}
+	ctx.JSON(http.StatusOK, result)
+}
+
+func (c *OrderController) getOrdersByIds(ctx *gin.Context, store database.OrderStore) {
+	var ids []uint
+	for _, param := range ctx.QueryArray("ids") {
+		for _, id := range strings.Split(param, ",") {
+			if id == "" {
+				continue
+			}
+			parsedID, err := strconv.ParseUint(id, 10, 0)
+			if err != nil {
+				apierror.Abort(ctx, apierror.New(http.StatusBadRequest, apierror.CodeInvalidID, id).WithField("ids"))
+				return
+			}
+			ids = append(ids, uint(parsedID))
+		}
+	}
+	if len(ids) == 0 || len(ids) > maxListLimit {
+		apierror.Abort(ctx, apierror.New(http.StatusBadRequest, apierror.CodeInvalidIDCount, maxListLimit).WithField("ids"))
+		return
+	}
+	orders, missing, err := store.GetOrderByIds(ids...)
+	if err != nil {
+		apierror.Abort(ctx, err)
+		return
+	}
+	if missing == nil {
+		missing = []uint{}
+	}
+	ctx.JSON(http.StatusOK, OrderBatchH{Orders: FromOrders(orders), Missing: missing})
+}
+
+const (
+	defaultListLimit = 20
+	maxListLimit     = 100
+)
+
+type OrderListH struct {
+	Orders []OrderResponse `json:"orders"`
+	Total  int64           `json:"total" example:"42"`
+	Limit  int             `json:"limit" example:"20"`
+	Next   *string         `json:"next" example:"/v1/orders?cursor=eyJ2IjoiMjAiLCJpZCI6MjB9&limit=20"`
+	Prev   *string         `json:"prev"`
+}
+
+type OrderBatchH struct {
+	Orders  []OrderResponse `json:"orders"`
+	Missing []uint          `json:"missing" example:"3"`
+}
+
+type OrderH struct {
+	Order OrderResponse `json:"order"`
+}
+
+type ItemH struct {
+	Item ItemResponse `json:"item"`
+}
+
+type ItemsH struct {
+	Items []ItemResponse `json:"items"`
+}
+
+type SuccessH struct {
+	Message string `example:"Operation successfull."`
+}
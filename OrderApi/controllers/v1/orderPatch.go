@@ -0,0 +1,218 @@
+package v1
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"assignment2.id/orderapi/apierror"
+	"assignment2.id/orderapi/controllers"
+	"assignment2.id/orderapi/models"
+	"assignment2.id/orderapi/policy"
+	"assignment2.id/orderapi/validation"
+	"github.com/gin-gonic/gin"
+)
+
+// patchItem is an item in a patchDocument.
+type patchItem struct {
+	ID          uint   `json:",omitempty"`
+	ItemCode    string `json:",omitempty"`
+	Description string `json:",omitempty"`
+	Quantity    uint   `json:",omitempty"`
+}
+
+// patchDocument is the JSON an order is patched as. Items is an object keyed
+// by item ID so a patch addresses an item by its ID rather than its position,
+// an item added under any other key is created. A patch may also set Items to
+// an array, which replaces every item. Fields removed by the patch, for
+// instance with an explicit null in a merge patch, are cleared.
+type patchDocument struct {
+	CustomerName string          `json:",omitempty"`
+	OrderedAt    *time.Time      `json:",omitempty"`
+	Items        json.RawMessage `json:",omitempty"`
+}
+
+func toPatchDocument(order models.Order) ([]byte, error) {
+	items := make(map[string]patchItem, len(order.Items))
+	for _, item := range order.Items {
+		items[strconv.FormatUint(uint64(item.ID), 10)] = patchItem{
+			ItemCode:    item.ItemCode,
+			Description: item.Description,
+			Quantity:    item.Quantity,
+		}
+	}
+	rawItems, err := json.Marshal(items)
+	if err != nil {
+		return nil, err
+	}
+	orderedAt := order.OrderedAt
+	return json.Marshal(patchDocument{
+		CustomerName: order.CustomerName,
+		OrderedAt:    &orderedAt,
+		Items:        rawItems,
+	})
+}
+
+// applyPatchDocument sets order to the content of the patched document.
+func applyPatchDocument(order *models.Order, raw []byte) error {
+	var doc patchDocument
+	if err := controllers.DecodeStrict(raw, &doc); err != nil {
+		return err
+	}
+	if doc.OrderedAt == nil {
+		return controllers.ErrOrderedAtNull
+	}
+	order.CustomerName = doc.CustomerName
+	order.OrderedAt = *doc.OrderedAt
+	order.Items = nil
+
+	trimmed := bytes.TrimSpace(doc.Items)
+	if len(trimmed) == 0 || bytes.Equal(trimmed, []byte("null")) {
+		return nil
+	}
+	if trimmed[0] == '[' {
+		var items []patchItem
+		if err := controllers.DecodeStrict(trimmed, &items); err != nil {
+			return err
+		}
+		for _, item := range items {
+			order.Items = append(order.Items, models.Item{
+				ID:          item.ID,
+				ItemCode:    item.ItemCode,
+				Description: item.Description,
+				Quantity:    item.Quantity,
+			})
+		}
+		return nil
+	}
+	var items map[string]*patchItem
+	if err := controllers.DecodeStrict(trimmed, &items); err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(items))
+	ids := make(map[string]uint, len(items))
+	for key := range items {
+		keys = append(keys, key)
+		// Keys that are not an ID, such as "new" or "-", add an item.
+		if parsed, err := strconv.ParseUint(key, 10, 0); err == nil && parsed != 0 {
+			ids[key] = uint(parsed)
+		}
+	}
+	// Existing items first by ID, then new items by key.
+	sort.Slice(keys, func(i, j int) bool {
+		idI, idJ := ids[keys[i]], ids[keys[j]]
+		if (idI == 0) != (idJ == 0) {
+			return idI != 0
+		}
+		if idI != idJ {
+			return idI < idJ
+		}
+		return keys[i] < keys[j]
+	})
+	for _, key := range keys {
+		item := items[key]
+		if item == nil {
+			continue
+		}
+		itemID := ids[key]
+		order.Items = append(order.Items, models.Item{
+			ID:          itemID,
+			ItemCode:    item.ItemCode,
+			Description: item.Description,
+			Quantity:    item.Quantity,
+		})
+	}
+	return nil
+}
+
+// clearedFields returns the JSON pointers of the top-level fields of the
+// patched document that the patch removed or set to null.
+func clearedFields(patched []byte) []string {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(patched, &fields); err != nil {
+		return nil
+	}
+	var cleared []string
+	for _, name := range []string{"CustomerName", "OrderedAt", "Items"} {
+		if value, ok := fields[name]; !ok || bytes.Equal(bytes.TrimSpace(value), []byte("null")) {
+			cleared = append(cleared, "/"+name)
+		}
+	}
+	return cleared
+}
+
+// PatchOrder godoc
+// @Summary      Patch an order
+// @Description  patch an order with a JSON Merge Patch (RFC 7396, Content-Type application/merge-patch+json)
+// @Description  or a JSON Patch (RFC 6902, Content-Type application/json-patch+json).
+// @Description  The patched document is {"CustomerName": "...", "OrderedAt": "...", "Items": {"<item ID>": {"ItemCode": "...", "Description": "...", "Quantity": 1}}}.
+// @Description  Items are keyed by item ID: patch /Items/5/Quantity to change item 5, remove /Items/5 to delete it,
+// @Description  and add an item under a key that is not an ID, such as /Items/new, to create one.
+// @Description  Setting Items to an array replaces every item. An explicit null in a merge patch clears the field.
+// @Tags         orders
+// @Accept       json
+// @Produce      json
+// @Param        orderID path uint true "ID number of the order to be patched."
+// @Param        patch body object true "JSON Merge Patch or JSON Patch document."
+// @Param        If-Match header string true "ETag of the order from GetOrder, or *."
+// @Success      200  {object}  OrderH
+// @Header       200  {string}  ETag  "ETag of the patched order."
+// @Failure      400  {object}  apierror.Problem
+// @Failure      401  {object}  apierror.Problem
+// @Failure      403  {object}  apierror.Problem
+// @Failure      404  {object}  apierror.Problem
+// @Failure      409  {object}  apierror.Problem
+// @Failure      412  {object}  apierror.Problem
+// @Failure      415  {object}  apierror.Problem
+// @Failure      422  {object}  apierror.Problem
+// @Failure      428  {object}  apierror.Problem
+// @Failure      429  {object}  apierror.Problem
+// @Failure      500  {object}  apierror.Problem
+// @Failure      504  {object}  apierror.Problem
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /orders/{orderID} [patch]
+func (c *OrderController) PatchOrder(ctx *gin.Context) {
+	store, ok := controllers.Authorize(ctx, c.store, policy.UpdateOrder)
+	if !ok {
+		return
+	}
+	parsedID, ok := controllers.PathID(ctx, "orderID")
+	if !ok {
+		return
+	}
+	version, ok := controllers.RequireIfMatch(ctx, store, parsedID)
+	if !ok {
+		return
+	}
+	apply, ok := controllers.ReadPatch(ctx)
+	if !ok {
+		return
+	}
+
+	order, err := store.PatchOrderById(parsedID, version, func(order *models.Order) error {
+		doc, err := toPatchDocument(*order)
+		if err != nil {
+			return err
+		}
+		patched, err := apply(doc)
+		if err != nil {
+			return controllers.PatchError(err)
+		}
+		if err := applyPatchDocument(order, patched); err != nil {
+			return controllers.PatchError(err)
+		}
+		return validation.PatchedOrder(order, clearedFields(patched))
+	})
+	if err != nil {
+		apierror.Abort(ctx, err)
+		return
+	}
+	ctx.Header("ETag", controllers.ETag(order.Version))
+	ctx.JSON(http.StatusOK, gin.H{
+		"order": FromOrder(order),
+	})
+}
@@ -0,0 +1,94 @@
+package v1
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"assignment2.id/orderapi/auth"
+	"assignment2.id/orderapi/controllers"
+	"assignment2.id/orderapi/database"
+	"assignment2.id/orderapi/models"
+	"github.com/gin-gonic/gin"
+)
+
+// newTestRouter returns the order routes over store, for an anonymous
+// principal with every role.
+func newTestRouter(store database.OrderStore) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(controllers.ErrorHandler(), auth.Anonymous())
+	NewOrderController(store, 0, 0).Register(router)
+	return router
+}
+
+// serve sends a request with body to router and returns the response.
+func serve(router http.Handler, method, path, body string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestPatchOrder(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		patch       string
+		status      int
+		customer    string
+		items       []string
+	}{
+		{"merge keeps missing keys", controllers.MergePatchType, `{"Items": {"new": {"ItemCode": "Z", "Quantity": 1}}}`, http.StatusOK, "A", []string{"X", "Y", "Z"}},
+		{"merge null clears the name", controllers.MergePatchType, `{"CustomerName": null}`, http.StatusOK, "", []string{"X", "Y"}},
+		{"merge empty name is required", controllers.MergePatchType, `{"CustomerName": ""}`, http.StatusUnprocessableEntity, "", nil},
+		{"merge null deletes an item", controllers.MergePatchType, `{"Items": {"1": null}}`, http.StatusOK, "A", []string{"Y"}},
+		{"merge null clears the items", controllers.MergePatchType, `{"Items": null}`, http.StatusOK, "A", nil},
+		{"merge null OrderedAt", controllers.MergePatchType, `{"OrderedAt": null}`, http.StatusUnprocessableEntity, "", nil},
+		{"json patch remove clears the name", controllers.JSONPatchType, `[{"op": "remove", "path": "/CustomerName"}]`, http.StatusOK, "", []string{"X", "Y"}},
+		{"json patch replace", controllers.JSONPatchType, `[{"op": "replace", "path": "/Items/2/Quantity", "value": 3}]`, http.StatusOK, "A", []string{"X", "Y"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := database.NewMemoryStore()
+			order := models.Order{CustomerName: "A", Items: []models.Item{{ItemCode: "X", Quantity: 1}, {ItemCode: "Y", Quantity: 1}}}
+			if err := store.CreateOrder(&order); err != nil {
+				t.Fatal(err)
+			}
+			w := serve(newTestRouter(store), http.MethodPatch, "/orders/1", tt.patch, http.Header{
+				"Content-Type": {tt.contentType},
+				"If-Match":     {"*"},
+			})
+			if w.Code != tt.status {
+				t.Fatalf("got status %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+			var body struct {
+				Order struct {
+					CustomerName string
+					Items        []struct{ ItemCode string }
+				} `json:"order"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Order.CustomerName != tt.customer {
+				t.Errorf("got customer %q, want %q", body.Order.CustomerName, tt.customer)
+			}
+			var codes []string
+			for _, item := range body.Order.Items {
+				codes = append(codes, item.ItemCode)
+			}
+			if strings.Join(codes, ",") != strings.Join(tt.items, ",") {
+				t.Errorf("got items %v, want %v", codes, tt.items)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package database
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrAPIKeyNotFound error = errors.New("API key tidak ditemukan.")
+
+// APIKey is a static credential of a principal. Only the SHA-256 hash of the
+// key is stored, the key itself is shown once when it is created.
+type APIKey struct {
+	ID uint `gorm:"primaryKey"`
+	// Name is the subject the key authenticates as.
+	Name string `gorm:"size:255;not null"`
+	// Prefix is the start of the key, to tell keys apart without the key.
+	Prefix string `gorm:"size:16;not null"`
+	Hash   string `gorm:"size:64;not null;uniqueIndex"`
+	// Roles is the comma separated list of the roles of the key.
+	Roles string `gorm:"size:255;not null"`
+	// TenantID is the tenant the key is limited to, empty for every tenant.
+	TenantID  string    `gorm:"size:64;not null;default:'';index"`
+	CreatedAt time.Time `gorm:"not null"`
+	RevokedAt *time.Time
+}
+
+func (s *GormStore) CreateAPIKey(key *APIKey) error {
+	if s.scope.Tenant != "" {
+		key.TenantID = s.scope.Tenant
+	}
+	return translateDuplicate(s.session(func(db *gorm.DB) error {
+		return db.Create(key).Error
+	}))
+}
+
+func (s *GormStore) GetAPIKeyByHash(hash string) (APIKey, error) {
+	var key APIKey
+	err := s.session(func(db *gorm.DB) error {
+		return db.Where("hash = ? AND revoked_at IS NULL", hash).Take(&key).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return key, ErrAPIKeyNotFound
+	}
+	return key, err
+}
+
+func (s *GormStore) ListAPIKeys() ([]APIKey, error) {
+	var keys []APIKey
+	err := s.session(func(db *gorm.DB) error {
+		return db.Scopes(s.tenanted).Order("id").Find(&keys).Error
+	})
+	return keys, err
+}
+
+func (s *GormStore) RevokeAPIKey(id uint) (APIKey, error) {
+	var key APIKey
+	err := s.transaction(func(tx *gorm.DB) error {
+		if err := tx.Scopes(s.tenanted).Take(&key, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrAPIKeyNotFound
+			}
+			return err
+		}
+		if key.RevokedAt != nil {
+			return nil
+		}
+		now := time.Now()
+		key.RevokedAt = &now
+		return tx.Model(&key).Update("revoked_at", now).Error
+	})
+	return key, err
+}
@@ -1,136 +1,134 @@
 package database
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"log"
 	"time"
 
+	"assignment2.id/orderapi/config"
 	"assignment2.id/orderapi/models"
-	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
-var (
-	host   = "localhost"
-	user   = "postgres"
-	dbPort = "5432"
-	dbName = "assignment2db"
-	db     *gorm.DB
-	err    error
-)
+// ErrRecordNotFound is returned by every OrderStore when no order has the requested id.
+var ErrRecordNotFound error = gorm.ErrRecordNotFound
 
-func StartDB() {
-	var password string
-	fmt.Println("Enter db password (not hidden, be careful of shoulder surfing)")
-	fmt.Scanln(&password)
-	config := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable", host, user, password, dbName, dbPort)
-	db, err = gorm.Open(postgres.Open(config), &gorm.Config{})
-	if err != nil {
-		log.Fatal("error connecting to database: ", err)
-	}
-	db.Debug().AutoMigrate(models.Order{}, models.Item{})
-}
+// ErrDuplicateKey is returned when creating an order, or an API key, whose
+// ID or hash is already taken.
+var ErrDuplicateKey error = errors.New("duplicate key")
+var ErrNotDeleted error = errors.New("Order tidak sedang terhapus.")
+var ErrItemNotInOrder error = errors.New("Item bukan milik order ini.")
+var ErrVersionMismatch error = errors.New("Order sudah diubah oleh permintaan lain.")
+var ErrItemNotFound error = errors.New("Item tidak ditemukan di order ini.")
 
-func GetDB() *gorm.DB {
-	return db
-}
+// OrderStore persists orders and their items.
+type OrderStore interface {
+	// Scoped returns a view of the store that only sees the orders, and their
+	// items, in scope.
+	Scoped(scope Scope) OrderStore
+	// WithContext returns a view of the store running its queries in ctx,
+	// cancelled with it, and logging to the logger of ctx.
+	WithContext(ctx context.Context) OrderStore
 
-func CreateOrder(order *models.Order) error {
-	if db == nil {
-		return errors.New("DB hasn't started yet.")
-	}
-	var zero time.Time
-	if order.OrderedAt == zero {
-		order.OrderedAt = time.Now()
-	}
-	err := db.Create(order).Error
-	if err != nil {
-		return err
-	}
-	log.Println("New Order Data: ", order)
-	return nil
-}
+	CreateOrder(order *models.Order) error
+	GetOrderById(id uint) (models.Order, error)
+	// GetOrderByIds returns the orders with the given ids, in the order the
+	// ids were given, and the ids that matched no order.
+	GetOrderByIds(ids ...uint) ([]models.Order, []uint, error)
+	ListOrders(q OrderListQuery) (OrderPage, error)
+	// The write methods taking a version fail with ErrVersionMismatch unless
+	// the order is at that version, 0 matches any version. Every change to an
+	// order or its items increments its version.
+	UpdateOrderById(id uint, argOrder *models.Order, version uint) error
+	// PatchOrderById loads the order, lets patch modify it and saves the
+	// result as a whole, in one transaction. Items keep their ID, items with
+	// ID 0 are created and items left out are deleted. An item ID from
+	// another order fails with ErrItemNotInOrder.
+	PatchOrderById(id uint, version uint, patch func(order *models.Order) error) (models.Order, error)
+	// The item methods return ErrRecordNotFound for a missing order and
+	// ErrItemNotFound for an item that is missing or belongs to another order.
+	GetItems(orderID uint) ([]models.Item, error)
+	GetItem(orderID, itemID uint) (models.Item, error)
+	// The item write methods also return the version the order is at after
+	// the write.
+	CreateItem(orderID uint, item *models.Item, version uint) (uint, error)
+	// UpdateItem lets update modify the item and saves the result.
+	UpdateItem(orderID, itemID, version uint, update func(item *models.Item) error) (models.Item, uint, error)
+	DeleteItem(orderID, itemID, version uint) (uint, error)
+	// DeleteOrderById soft deletes the order and its items, hiding them
+	// until RestoreOrderById or until they are purged.
+	DeleteOrderById(id uint, version uint) error
+	RestoreOrderById(id uint) error
+	// PurgeOrderById permanently deletes the order, soft deleted or not.
+	PurgeOrderById(id uint, version uint) error
+	// PurgeDeletedBefore permanently deletes the orders soft deleted before t
+	// and returns how many were purged.
+	PurgeDeletedBefore(t time.Time) (int64, error)
 
-func GetOrderById(id uint) (models.Order, error) {
-	order := models.Order{}
-	if db == nil {
-		return order, errors.New("DB hasn't started yet.")
-	}
-	err := db.Model(&models.Order{}).Preload("Items").Take(&order, id).Error
-	if err != nil {
-		return order, err
-	}
-	return order, nil
-}
+	// GetIdempotencyKey returns the record of key, or ErrRecordNotFound when
+	// there is none or it was created before since.
+	GetIdempotencyKey(key string, since time.Time) (IdempotencyKey, error)
+	// CreateOrderOnce creates the order and saves record, with the response
+	// respond renders for the created order, in one transaction. A record of
+	// the key created before since is replaced, a newer one fails with
+	// ErrIdempotencyKeyUsed and nothing is created.
+	CreateOrderOnce(order *models.Order, record *IdempotencyKey, since time.Time, respond func(order *models.Order) ([]byte, error)) error
+	// PurgeIdempotencyKeysBefore deletes the records created before t and
+	// returns how many were deleted.
+	PurgeIdempotencyKeysBefore(t time.Time) (int64, error)
 
-func GetOrderByIds(ids ...uint) ([]models.Order, error) {
-	if len(ids) == 0 {
-		return nil, nil
-	}
-	if len(ids) == 1 {
-		order, err := GetOrderById(ids[0])
-		return []models.Order{order}, err
-	}
-	if db == nil {
-		return nil, errors.New("DB hasn't started yet.")
-	}
-	orders := make([]models.Order, 2)
-	err := db.Model(&models.Order{}).Preload("Items").Find(&orders, ids).Error
-	if err != nil {
-		return nil, err
-	}
-	if len(orders) == 0 {
-		return nil, errors.New("No ID match.")
-	}
-	return orders, nil
+	// CreateAPIKey saves key, assigning its ID and CreatedAt.
+	CreateAPIKey(key *APIKey) error
+	// GetAPIKeyByHash returns the unrevoked key with hash, or
+	// ErrAPIKeyNotFound.
+	GetAPIKeyByHash(hash string) (APIKey, error)
+	ListAPIKeys() ([]APIKey, error)
+	// RevokeAPIKey revokes the key with id, which keeps the time it was
+	// first revoked. It returns ErrAPIKeyNotFound when there is no such key.
+	RevokeAPIKey(id uint) (APIKey, error)
+
+	// Ping checks the database can be reached.
+	Ping(ctx context.Context) error
+	// Close releases the database connections. The store, and its scoped
+	// views, must not be used afterwards.
+	Close() error
 }
 
-func UpdateOrderById(id uint, argOrder *models.Order) error {
-	dbOrder, err := GetOrderById(id)
-	if err != nil {
-		return err
-	}
-	if argOrder.CustomerName != "" {
-		dbOrder.CustomerName = argOrder.CustomerName
-	}
-	var temp time.Time
-	if argOrder.OrderedAt != temp {
-		dbOrder.OrderedAt = argOrder.OrderedAt
-	}
-	err = db.Transaction(func(tx *gorm.DB) error {
-		if err := tx.Save(&dbOrder).Error; err != nil {
-			return err
-		}
-		if argOrder.Items != nil {
-			if err := tx.Model(&dbOrder).Association("Items").Replace(argOrder.Items); err != nil {
-				return err
-			}
-		}
-		return nil
-	})
-	if err == nil {
-		log.Printf("Updated order: %+v\n", dbOrder)
-	}
-	return err
+// Open returns the OrderStore selected by cfg.Driver. redactPII masks the
+// string values of the SQL statements it logs.
+func Open(cfg config.DBConfig, redactPII bool) (OrderStore, error) {
+	switch cfg.Driver {
+	case "postgres":
+		return NewPostgresStore(cfg, redactPII)
+	case "sqlite":
+		return NewSQLiteStore(cfg.SQLitePath, redactPII)
+	case "memory":
+		return NewMemoryStore(), nil
+	}
+	return nil, fmt.Errorf("unknown db driver %q", cfg.Driver)
 }
 
-func DeleteOrderById(id uint) error {
-	order, err := GetOrderById(id)
-	if err != nil {
-		return err
-	}
-	err = db.Transaction(func(tx *gorm.DB) error {
-		if err := tx.Model(&order).Association("Items").Clear(); err != nil {
-			return err
+// sortByIds arranges found in the order of ids, dropping duplicate ids, and
+// returns the ids missing from found.
+func sortByIds(found []models.Order, ids []uint) ([]models.Order, []uint) {
+	byID := make(map[uint]models.Order, len(found))
+	for _, order := range found {
+		byID[order.ID] = order
+	}
+	orders := make([]models.Order, 0, len(found))
+	var missing []uint
+	seen := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			continue
 		}
-		if err := tx.Delete(&order, id).Error; err != nil {
-			return err
+		seen[id] = true
+		if order, ok := byID[id]; ok {
+			orders = append(orders, order)
+		} else {
+			missing = append(missing, id)
 		}
-		return nil
-	})
-	if err == nil {
-		log.Println("Order with id", id, "has been successfully deleted")
 	}
-	return err
+	return orders, missing
 }
@@ -0,0 +1,24 @@
+package database
+
+import "errors"
+
+// Unique constraint violations of the drivers.
+const (
+	postgresUniqueViolation = "23505"
+	sqlitePrimaryKey        = 1555
+	sqliteUnique            = 2067
+)
+
+// translateDuplicate turns the unique constraint violation of a driver into
+// ErrDuplicateKey, so every store reports a taken ID the same way.
+func translateDuplicate(err error) error {
+	var pgErr interface{ SQLState() string }
+	if errors.As(err, &pgErr) && pgErr.SQLState() == postgresUniqueViolation {
+		return ErrDuplicateKey
+	}
+	var sqliteErr interface{ Code() int }
+	if errors.As(err, &sqliteErr) && (sqliteErr.Code() == sqlitePrimaryKey || sqliteErr.Code() == sqliteUnique) {
+		return ErrDuplicateKey
+	}
+	return err
+}
@@ -0,0 +1,126 @@
+package database
+
+import (
+	"errors"
+
+	"assignment2.id/orderapi/models"
+	"gorm.io/gorm"
+)
+
+// orderTenant returns the tenant of the order with orderID, or
+// ErrRecordNotFound unless the order exists in the scope of the store.
+func (s *GormStore) orderTenant(tx *gorm.DB, orderID uint) (string, error) {
+	var tenants []string
+	if err := tx.Model(&models.Order{}).Scopes(s.owned).Where("id = ?", orderID).Pluck("tenant_id", &tenants).Error; err != nil {
+		return "", err
+	}
+	if len(tenants) == 0 {
+		return "", ErrRecordNotFound
+	}
+	return tenants[0], nil
+}
+
+func (s *GormStore) takeItem(tx *gorm.DB, orderID, itemID uint) (models.Item, error) {
+	var item models.Item
+	if _, err := s.orderTenant(tx, orderID); err != nil {
+		return item, err
+	}
+	err := tx.Scopes(s.tenanted).Where("order_id = ?", orderID).Take(&item, itemID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return item, ErrItemNotFound
+	}
+	return item, err
+}
+
+func (s *GormStore) GetItems(orderID uint) ([]models.Item, error) {
+	items := []models.Item{}
+	err := s.session(func(db *gorm.DB) error {
+		if _, err := s.orderTenant(db, orderID); err != nil {
+			return err
+		}
+		return db.Scopes(s.tenanted).Where("order_id = ?", orderID).Order("id").Find(&items).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (s *GormStore) GetItem(orderID, itemID uint) (models.Item, error) {
+	var item models.Item
+	err := s.session(func(db *gorm.DB) error {
+		var err error
+		item, err = s.takeItem(db, orderID, itemID)
+		return err
+	})
+	return item, err
+}
+
+func (s *GormStore) CreateItem(orderID uint, item *models.Item, version uint) (uint, error) {
+	var newVersion uint
+	err := s.transaction(func(tx *gorm.DB) error {
+		var err error
+		if newVersion, err = s.bumpVersion(tx, orderID, version); err != nil {
+			return err
+		}
+		tenant, err := s.orderTenant(tx, orderID)
+		if err != nil {
+			return err
+		}
+		item.ID = 0
+		item.OrderID = orderID
+		item.TenantID = tenant
+		return tx.Create(item).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	s.log().Info("item created", "order_id", orderID, "item_id", item.ID, "version", newVersion)
+	return newVersion, nil
+}
+
+func (s *GormStore) UpdateItem(orderID, itemID, version uint, update func(item *models.Item) error) (models.Item, uint, error) {
+	var item models.Item
+	var newVersion uint
+	err := s.transaction(func(tx *gorm.DB) error {
+		var err error
+		if newVersion, err = s.bumpVersion(tx, orderID, version); err != nil {
+			return err
+		}
+		if item, err = s.takeItem(tx, orderID, itemID); err != nil {
+			return err
+		}
+		tenant := item.TenantID
+		if err := update(&item); err != nil {
+			return err
+		}
+		item.ID = itemID
+		item.OrderID = orderID
+		item.TenantID = tenant
+		return tx.Save(&item).Error
+	})
+	if err != nil {
+		return models.Item{}, 0, err
+	}
+	s.log().Info("item updated", "order_id", orderID, "item_id", itemID, "version", newVersion)
+	return item, newVersion, nil
+}
+
+func (s *GormStore) DeleteItem(orderID, itemID, version uint) (uint, error) {
+	var newVersion uint
+	err := s.transaction(func(tx *gorm.DB) error {
+		var err error
+		if newVersion, err = s.bumpVersion(tx, orderID, version); err != nil {
+			return err
+		}
+		if _, err := s.takeItem(tx, orderID, itemID); err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&models.Item{}, itemID).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	s.log().Info("item deleted", "order_id", orderID, "item_id", itemID, "version", newVersion)
+	return newVersion, nil
+}
@@ -0,0 +1,369 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"assignment2.id/orderapi/config"
+	"assignment2.id/orderapi/logging"
+	"assignment2.id/orderapi/models"
+	"github.com/glebarez/sqlite"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GormStore is the OrderStore backed by a SQL database through GORM. It is
+// used for both the postgres and the sqlite driver.
+type GormStore struct {
+	db    *gorm.DB
+	scope Scope
+	// rls sets the tenant of the scope for the row level security policies
+	// of postgres.
+	rls bool
+}
+
+// openGorm opens the database of dialector. redactPII masks the string values
+// of the statements logged.
+func openGorm(dialector gorm.Dialector, redactPII bool) (*GormStore, error) {
+	if redactPII {
+		dialector = redactedDialector{dialector}
+	}
+	db, err := gorm.Open(dialector, &gorm.Config{Logger: gormLogger{}})
+	if err != nil {
+		return nil, err
+	}
+	return &GormStore{db: db}, nil
+}
+
+func NewPostgresStore(cfg config.DBConfig, redactPII bool) (*GormStore, error) {
+	dsn := cfg.DSN()
+	if !cfg.RowLevelSecurity {
+		// The store filters the tenants itself, its connections bypass the
+		// row level security policies, which hide every row otherwise.
+		dsn += " app.bypass_rls=on"
+	}
+	store, err := openGorm(postgres.Open(dsn), redactPII)
+	if err != nil {
+		return nil, err
+	}
+	sqlDB, err := store.db.DB()
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(time.Duration(cfg.ConnMaxLifetime))
+	store.rls = cfg.RowLevelSecurity
+	return store, nil
+}
+
+// NewSQLiteStore opens the sqlite database file at path, ":memory:" gives a
+// throwaway database.
+func NewSQLiteStore(path string, redactPII bool) (*GormStore, error) {
+	store, err := openGorm(sqlite.Open(path+"?_pragma=foreign_keys(1)"), redactPII)
+	if err != nil {
+		return nil, err
+	}
+	sqlDB, err := store.db.DB()
+	if err != nil {
+		return nil, err
+	}
+	// sqlite allows a single writer, and every connection to ":memory:" is a separate database.
+	sqlDB.SetMaxOpenConns(1)
+	return store, nil
+}
+
+func (s *GormStore) DB() *gorm.DB {
+	return s.db
+}
+
+func (s *GormStore) WithContext(ctx context.Context) OrderStore {
+	return &GormStore{db: s.db.WithContext(ctx), scope: s.scope, rls: s.rls}
+}
+
+func (s *GormStore) Ping(ctx context.Context) error {
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+func (s *GormStore) Close() error {
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
+func (s *GormStore) CreateOrder(order *models.Order) error {
+	s.scope.stamp(order)
+	return s.session(func(db *gorm.DB) error {
+		return createOrder(db, order)
+	})
+}
+
+func createOrder(db *gorm.DB, order *models.Order) error {
+	var zero time.Time
+	if order.OrderedAt == zero {
+		order.OrderedAt = time.Now()
+	}
+	order.Version = 1
+	err := db.Create(order).Error
+	if err != nil {
+		return translateDuplicate(err)
+	}
+	logger(db).Info("order created", "order_id", order.ID, logging.CustomerName(order.CustomerName), "items", len(order.Items))
+	return nil
+}
+
+func (s *GormStore) GetOrderById(id uint) (models.Order, error) {
+	order := models.Order{}
+	err := s.session(func(db *gorm.DB) error {
+		return db.Model(&models.Order{}).Scopes(s.owned).Preload("Items").Take(&order, id).Error
+	})
+	if err != nil {
+		return order, err
+	}
+	return order, nil
+}
+
+func (s *GormStore) GetOrderByIds(ids ...uint) ([]models.Order, []uint, error) {
+	if len(ids) == 0 {
+		return nil, nil, nil
+	}
+	var found []models.Order
+	err := s.session(func(db *gorm.DB) error {
+		return db.Model(&models.Order{}).Scopes(s.owned).Preload("Items").Find(&found, ids).Error
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	orders, missing := sortByIds(found, ids)
+	return orders, missing, nil
+}
+
+// versionConflict tells, after a write guarded by a version matched no row,
+// whether the order is missing or was changed in the meantime.
+func (s *GormStore) versionConflict(tx *gorm.DB, id uint) error {
+	var count int64
+	if err := tx.Model(&models.Order{}).Scopes(s.owned).Where("id = ?", id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrRecordNotFound
+	}
+	return ErrVersionMismatch
+}
+
+// bumpVersion increments the version of the order, failing with
+// ErrVersionMismatch unless it is at version. Version 0 matches any version.
+// The update also locks the row until the transaction ends. An order out of
+// the scope of the store fails with ErrRecordNotFound. It returns the new
+// version.
+func (s *GormStore) bumpVersion(tx *gorm.DB, id, version uint) (uint, error) {
+	query := tx.Model(&models.Order{}).Scopes(s.owned).Where("id = ?", id)
+	if version != 0 {
+		query = query.Where("version = ?", version)
+	}
+	result := query.UpdateColumn("version", gorm.Expr("version + 1"))
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return 0, s.versionConflict(tx, id)
+	}
+	var versions []uint
+	if err := tx.Model(&models.Order{}).Where("id = ?", id).Pluck("version", &versions).Error; err != nil {
+		return 0, err
+	}
+	if len(versions) == 0 {
+		return 0, ErrRecordNotFound
+	}
+	return versions[0], nil
+}
+
+func (s *GormStore) UpdateOrderById(id uint, argOrder *models.Order, version uint) error {
+	var dbOrder models.Order
+	err := s.transaction(func(tx *gorm.DB) error {
+		if _, err := s.bumpVersion(tx, id, version); err != nil {
+			return err
+		}
+		if err := tx.Preload("Items").Take(&dbOrder, id).Error; err != nil {
+			return err
+		}
+		if argOrder.CustomerName != "" {
+			dbOrder.CustomerName = argOrder.CustomerName
+		}
+		var temp time.Time
+		if argOrder.OrderedAt != temp {
+			dbOrder.OrderedAt = argOrder.OrderedAt
+		}
+		if err := tx.Omit(clause.Associations).Save(&dbOrder).Error; err != nil {
+			return err
+		}
+		if argOrder.Items != nil {
+			// Previous items are deleted rather than unlinked so no orphan is left behind.
+			if err := tx.Unscoped().Where("order_id = ?", id).Delete(&models.Item{}).Error; err != nil {
+				return err
+			}
+			for i := range argOrder.Items {
+				argOrder.Items[i].OrderID = id
+				argOrder.Items[i].TenantID = dbOrder.TenantID
+			}
+			if len(argOrder.Items) > 0 {
+				if err := tx.Create(&argOrder.Items).Error; err != nil {
+					return err
+				}
+			}
+			dbOrder.Items = argOrder.Items
+		}
+		return nil
+	})
+	if err == nil {
+		argOrder.Version = dbOrder.Version
+		s.log().Info("order updated", "order_id", id, "version", dbOrder.Version, logging.CustomerName(dbOrder.CustomerName), "items", len(dbOrder.Items))
+	}
+	return err
+}
+
+func (s *GormStore) PatchOrderById(id uint, version uint, patch func(order *models.Order) error) (models.Order, error) {
+	var order models.Order
+	err := s.transaction(func(tx *gorm.DB) error {
+		if _, err := s.bumpVersion(tx, id, version); err != nil {
+			return err
+		}
+		if err := tx.Preload("Items").Take(&order, id).Error; err != nil {
+			return err
+		}
+		current := order
+		previous := make(map[uint]models.Item, len(order.Items))
+		for _, item := range order.Items {
+			previous[item.ID] = item
+		}
+		if err := patch(&order); err != nil {
+			return err
+		}
+		order.ID = id
+		order.Version = current.Version
+		order.Owner = current.Owner
+		order.TenantID = current.TenantID
+		if err := tx.Omit(clause.Associations).Save(&order).Error; err != nil {
+			return err
+		}
+		kept := make(map[uint]bool, len(order.Items))
+		for i := range order.Items {
+			item := &order.Items[i]
+			item.OrderID = id
+			item.TenantID = order.TenantID
+			if item.ID == 0 {
+				if err := tx.Create(item).Error; err != nil {
+					return err
+				}
+				continue
+			}
+			old, ok := previous[item.ID]
+			if !ok {
+				return fmt.Errorf("item %d: %w", item.ID, ErrItemNotInOrder)
+			}
+			kept[item.ID] = true
+			if old != *item {
+				if err := tx.Save(item).Error; err != nil {
+					return err
+				}
+			}
+		}
+		for itemID := range previous {
+			if !kept[itemID] {
+				if err := tx.Unscoped().Delete(&models.Item{}, itemID).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return models.Order{}, err
+	}
+	s.log().Info("order patched", "order_id", id, "version", order.Version, logging.CustomerName(order.CustomerName), "items", len(order.Items))
+	return order, nil
+}
+
+func (s *GormStore) DeleteOrderById(id uint, version uint) error {
+	err := s.transaction(func(tx *gorm.DB) error {
+		query := tx.Scopes(s.owned)
+		if version != 0 {
+			query = query.Where("version = ?", version)
+		}
+		result := query.Delete(&models.Order{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return s.versionConflict(tx, id)
+		}
+		return tx.Where("order_id = ?", id).Delete(&models.Item{}).Error
+	})
+	if err == nil {
+		s.log().Info("order deleted", "order_id", id)
+	}
+	return err
+}
+
+func (s *GormStore) RestoreOrderById(id uint) error {
+	err := s.transaction(func(tx *gorm.DB) error {
+		var order models.Order
+		if err := tx.Unscoped().Scopes(s.owned).Take(&order, id).Error; err != nil {
+			return err
+		}
+		if !order.DeletedAt.Valid {
+			return ErrNotDeleted
+		}
+		// The restore is a change like any other, it bumps the version.
+		restore := map[string]interface{}{"deleted_at": nil, "version": gorm.Expr("version + 1")}
+		if err := tx.Unscoped().Model(&order).Updates(restore).Error; err != nil {
+			return err
+		}
+		// UpdateColumn skips the hooks, which would check an empty Item.
+		return tx.Unscoped().Model(&models.Item{}).Where("order_id = ?", id).UpdateColumn("deleted_at", nil).Error
+	})
+	if err == nil {
+		s.log().Info("order restored", "order_id", id)
+	}
+	return err
+}
+
+// PurgeOrderById permanently deletes the order, its items go with it through ON DELETE CASCADE.
+func (s *GormStore) PurgeOrderById(id uint, version uint) error {
+	err := s.session(func(db *gorm.DB) error {
+		query := db.Unscoped().Scopes(s.owned)
+		if version != 0 {
+			query = query.Where("version = ?", version)
+		}
+		result := query.Delete(&models.Order{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return s.versionConflict(db.Unscoped(), id)
+		}
+		return nil
+	})
+	if err == nil {
+		s.log().Info("order purged", "order_id", id)
+	}
+	return err
+}
+
+func (s *GormStore) PurgeDeletedBefore(t time.Time) (int64, error) {
+	var purged int64
+	err := s.session(func(db *gorm.DB) error {
+		result := db.Unscoped().Scopes(s.owned).Where("deleted_at < ?", t).Delete(&models.Order{})
+		purged = result.RowsAffected
+		return result.Error
+	})
+	return purged, err
+}
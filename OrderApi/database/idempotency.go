@@ -0,0 +1,72 @@
+package database
+
+import (
+	"errors"
+	"time"
+
+	"assignment2.id/orderapi/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrIdempotencyKeyUsed error = errors.New("Idempotency-Key sudah dipakai.")
+
+// IdempotencyKey records the response given to a request carrying an
+// Idempotency-Key header, so a retry of the request gets the same response.
+type IdempotencyKey struct {
+	Key string `gorm:"primaryKey;size:255"`
+	// RequestHash tells a retry from another request reusing the key.
+	RequestHash string `gorm:"size:64;not null"`
+	StatusCode  int    `gorm:"not null"`
+	Response    []byte
+	// OrderVersion is the version of the created order, the ETag of the
+	// response.
+	OrderVersion uint      `gorm:"not null;default:1"`
+	CreatedAt    time.Time `gorm:"not null;index"`
+}
+
+func (s *GormStore) GetIdempotencyKey(key string, since time.Time) (IdempotencyKey, error) {
+	var record IdempotencyKey
+	err := s.session(func(db *gorm.DB) error {
+		return db.Where("key = ? AND created_at >= ?", key, since).Take(&record).Error
+	})
+	return record, err
+}
+
+func (s *GormStore) CreateOrderOnce(order *models.Order, record *IdempotencyKey, since time.Time, respond func(order *models.Order) ([]byte, error)) error {
+	s.scope.stamp(order)
+	return s.transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("key = ? AND created_at < ?", record.Key, since).Delete(&IdempotencyKey{}).Error; err != nil {
+			return err
+		}
+		// A concurrent request with the same key waits here until the
+		// first one commits, then inserts nothing.
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrIdempotencyKeyUsed
+		}
+		if err := createOrder(tx, order); err != nil {
+			return err
+		}
+		response, err := respond(order)
+		if err != nil {
+			return err
+		}
+		record.Response = response
+		record.OrderVersion = order.Version
+		return tx.Model(record).Updates(map[string]interface{}{"response": response, "order_version": order.Version}).Error
+	})
+}
+
+func (s *GormStore) PurgeIdempotencyKeysBefore(t time.Time) (int64, error) {
+	var purged int64
+	err := s.session(func(db *gorm.DB) error {
+		result := db.Where("created_at < ?", t).Delete(&IdempotencyKey{})
+		purged = result.RowsAffected
+		return result.Error
+	})
+	return purged, err
+}
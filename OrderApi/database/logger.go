@@ -0,0 +1,112 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"time"
+
+	"assignment2.id/orderapi/logging"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// slowQuery is how long a query runs before it is logged as a warning.
+const slowQuery = 200 * time.Millisecond
+
+// logger returns the logger of the context db runs in, which carries the ID
+// of the request.
+func logger(db *gorm.DB) *slog.Logger {
+	return logging.FromContext(db.Statement.Context)
+}
+
+func (s *GormStore) log() *slog.Logger {
+	return logger(s.db)
+}
+
+// gormLogger logs the queries of GORM to the logger of their context: every
+// query at debug level, slow queries as warnings and failed ones as errors.
+type gormLogger struct{}
+
+func (gormLogger) LogMode(gormlogger.LogLevel) gormlogger.Interface {
+	return gormLogger{}
+}
+
+func (gormLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	logging.FromContext(ctx).Info(fmt.Sprintf(msg, args...))
+}
+
+func (gormLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	logging.FromContext(ctx).Warn(fmt.Sprintf(msg, args...))
+}
+
+func (gormLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	logging.FromContext(ctx).Error(fmt.Sprintf(msg, args...))
+}
+
+func (gormLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	l := logging.FromContext(ctx)
+	elapsed := time.Since(begin)
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+		sql, rows := fc()
+		l.Error("query failed", "err", err, "sql", sql, "rows", rows, "duration", elapsed)
+	case elapsed > slowQuery:
+		sql, rows := fc()
+		l.Warn("slow query", "sql", sql, "rows", rows, "duration", elapsed)
+	case l.Enabled(ctx, slog.LevelDebug):
+		sql, rows := fc()
+		l.Debug("query", "sql", sql, "rows", rows, "duration", elapsed)
+	}
+}
+
+// redactedDialector explains the statements of the logs with their string
+// values, which may be the name of a customer, masked.
+type redactedDialector struct {
+	gorm.Dialector
+}
+
+func (d redactedDialector) Explain(sql string, vars ...interface{}) string {
+	masked := make([]interface{}, len(vars))
+	for i, v := range vars {
+		masked[i] = maskString(v)
+	}
+	return d.Dialector.Explain(sql, masked...)
+}
+
+// maskString returns logging.Redacted for the strings and byte slices, and
+// v for the other values.
+func maskString(v interface{}) interface{} {
+	if valuer, ok := v.(driver.Valuer); ok {
+		value, err := valuer.Value()
+		if err != nil {
+			return v
+		}
+		v = value
+	}
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() == reflect.String || rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() == reflect.Uint8 {
+		return logging.Redacted
+	}
+	return v
+}
+
+// The save points of nested transactions are passed on to the wrapped
+// dialector, which the embedding does not do.
+
+func (d redactedDialector) SavePoint(tx *gorm.DB, name string) error {
+	if savePointer, ok := d.Dialector.(gorm.SavePointerDialectorInterface); ok {
+		return savePointer.SavePoint(tx, name)
+	}
+	return gorm.ErrUnsupportedDriver
+}
+
+func (d redactedDialector) RollbackTo(tx *gorm.DB, name string) error {
+	if savePointer, ok := d.Dialector.(gorm.SavePointerDialectorInterface); ok {
+		return savePointer.RollbackTo(tx, name)
+	}
+	return gorm.ErrUnsupportedDriver
+}
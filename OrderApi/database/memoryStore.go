@@ -0,0 +1,624 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"assignment2.id/orderapi/models"
+	"gorm.io/gorm"
+)
+
+// MemoryStore is an OrderStore keeping orders in process memory, for tests
+// and local development. It is safe for concurrent use.
+type MemoryStore struct {
+	*memoryState
+	scope Scope
+}
+
+// memoryState is the data of a MemoryStore, shared by its scoped views.
+type memoryState struct {
+	mu              sync.RWMutex
+	orders          map[uint]models.Order
+	lastOrderID     uint
+	lastItemID      uint
+	idempotencyKeys map[string]IdempotencyKey
+	apiKeys         []APIKey
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{memoryState: &memoryState{
+		orders:          make(map[uint]models.Order),
+		idempotencyKeys: make(map[string]IdempotencyKey),
+	}}
+}
+
+// WithContext returns s, which neither queries a database nor logs.
+func (s *MemoryStore) WithContext(ctx context.Context) OrderStore {
+	return s
+}
+
+func (s *MemoryStore) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (s *MemoryStore) Close() error {
+	return nil
+}
+
+func copyOrder(order models.Order) models.Order {
+	if order.Items != nil {
+		order.Items = append([]models.Item(nil), order.Items...)
+	}
+	return order
+}
+
+// storeItems assigns ids to new items and links every item to orderID, in
+// tenant.
+func (s *MemoryStore) storeItems(orderID uint, tenant string, items []models.Item) ([]models.Item, error) {
+	stored := make([]models.Item, len(items))
+	for i, item := range items {
+		if err := item.BeforeSave(nil); err != nil {
+			return nil, err
+		}
+		if item.ID == 0 {
+			s.lastItemID++
+			item.ID = s.lastItemID
+		}
+		item.OrderID = orderID
+		item.TenantID = tenant
+		stored[i] = item
+	}
+	return stored, nil
+}
+
+func (s *MemoryStore) CreateOrder(order *models.Order) error {
+	var zero time.Time
+	if order.OrderedAt == zero {
+		order.OrderedAt = time.Now()
+	}
+	if err := order.BeforeCreate(nil); err != nil {
+		return err
+	}
+	s.scope.stamp(order)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.createOrder(order)
+}
+
+// createOrder stores a new order, s.mu must be held.
+func (s *MemoryStore) createOrder(order *models.Order) error {
+	orderID := order.ID
+	if orderID == 0 {
+		orderID = s.lastOrderID + 1
+	}
+	if _, ok := s.orders[orderID]; ok {
+		return ErrDuplicateKey
+	}
+	order.OrderedAt = order.OrderedAt.UTC()
+	items, err := s.storeItems(orderID, order.TenantID, order.Items)
+	if err != nil {
+		return err
+	}
+	if orderID > s.lastOrderID {
+		s.lastOrderID = orderID
+	}
+	order.ID = orderID
+	order.Items = items
+	order.Version = 1
+	s.orders[orderID] = copyOrder(*order)
+	return nil
+}
+
+func (s *MemoryStore) GetOrderById(id uint) (models.Order, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	order, ok := s.order(id)
+	if !ok || order.DeletedAt.Valid {
+		return models.Order{}, ErrRecordNotFound
+	}
+	return copyOrder(order), nil
+}
+
+func (s *MemoryStore) GetOrderByIds(ids ...uint) ([]models.Order, []uint, error) {
+	if len(ids) == 0 {
+		return nil, nil, nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var found []models.Order
+	for _, id := range ids {
+		if order, ok := s.order(id); ok && !order.DeletedAt.Valid {
+			found = append(found, copyOrder(order))
+		}
+	}
+	orders, missing := sortByIds(found, ids)
+	return orders, missing, nil
+}
+
+func (s *MemoryStore) UpdateOrderById(id uint, argOrder *models.Order, version uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	order, ok := s.order(id)
+	if !ok || order.DeletedAt.Valid {
+		return ErrRecordNotFound
+	}
+	if err := checkVersion(order, version); err != nil {
+		return err
+	}
+	if argOrder.CustomerName != "" {
+		order.CustomerName = argOrder.CustomerName
+	}
+	var temp time.Time
+	if argOrder.OrderedAt != temp {
+		order.OrderedAt = argOrder.OrderedAt.UTC()
+	}
+	if argOrder.Items != nil {
+		items, err := s.storeItems(id, order.TenantID, argOrder.Items)
+		if err != nil {
+			return err
+		}
+		order.Items = items
+		// The caller learns the IDs of the new items, as from GormStore.
+		copy(argOrder.Items, items)
+	}
+	order.Version++
+	argOrder.Version = order.Version
+	s.orders[id] = order
+	return nil
+}
+
+func (s *MemoryStore) PatchOrderById(id uint, version uint, patch func(order *models.Order) error) (models.Order, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored, ok := s.order(id)
+	if !ok || stored.DeletedAt.Valid {
+		return models.Order{}, ErrRecordNotFound
+	}
+	if err := checkVersion(stored, version); err != nil {
+		return models.Order{}, err
+	}
+	order := copyOrder(stored)
+	if err := patch(&order); err != nil {
+		return models.Order{}, err
+	}
+	order.ID = id
+	order.Owner = stored.Owner
+	order.TenantID = stored.TenantID
+	order.DeletedAt = stored.DeletedAt
+	order.Version = stored.Version + 1
+	order.OrderedAt = order.OrderedAt.UTC()
+	previous := make(map[uint]bool, len(stored.Items))
+	for _, item := range stored.Items {
+		previous[item.ID] = true
+	}
+	for _, item := range order.Items {
+		if item.ID != 0 && !previous[item.ID] {
+			return models.Order{}, fmt.Errorf("item %d: %w", item.ID, ErrItemNotInOrder)
+		}
+	}
+	items, err := s.storeItems(id, order.TenantID, order.Items)
+	if err != nil {
+		return models.Order{}, err
+	}
+	order.Items = items
+	s.orders[id] = order
+	return copyOrder(order), nil
+}
+
+// checkVersion fails with ErrVersionMismatch unless order is at version, 0
+// matches any version.
+func checkVersion(order models.Order, version uint) error {
+	if version != 0 && order.Version != version {
+		return ErrVersionMismatch
+	}
+	return nil
+}
+
+// liveOrder returns the order with id unless it is missing or soft deleted.
+func (s *MemoryStore) liveOrder(id uint) (models.Order, error) {
+	order, ok := s.order(id)
+	if !ok || order.DeletedAt.Valid {
+		return models.Order{}, ErrRecordNotFound
+	}
+	return order, nil
+}
+
+func itemIndex(order models.Order, itemID uint) (int, error) {
+	for i, item := range order.Items {
+		if item.ID == itemID {
+			return i, nil
+		}
+	}
+	return -1, ErrItemNotFound
+}
+
+func (s *MemoryStore) GetItems(orderID uint) ([]models.Item, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	order, err := s.liveOrder(orderID)
+	if err != nil {
+		return nil, err
+	}
+	return append([]models.Item{}, order.Items...), nil
+}
+
+func (s *MemoryStore) GetItem(orderID, itemID uint) (models.Item, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	order, err := s.liveOrder(orderID)
+	if err != nil {
+		return models.Item{}, err
+	}
+	i, err := itemIndex(order, itemID)
+	if err != nil {
+		return models.Item{}, err
+	}
+	return order.Items[i], nil
+}
+
+func (s *MemoryStore) CreateItem(orderID uint, item *models.Item, version uint) (uint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	order, err := s.liveOrder(orderID)
+	if err != nil {
+		return 0, err
+	}
+	if err := checkVersion(order, version); err != nil {
+		return 0, err
+	}
+	item.ID = 0
+	stored, err := s.storeItems(orderID, order.TenantID, []models.Item{*item})
+	if err != nil {
+		return 0, err
+	}
+	*item = stored[0]
+	order = copyOrder(order)
+	order.Items = append(order.Items, *item)
+	order.Version++
+	s.orders[orderID] = order
+	return order.Version, nil
+}
+
+func (s *MemoryStore) UpdateItem(orderID, itemID, version uint, update func(item *models.Item) error) (models.Item, uint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	order, err := s.liveOrder(orderID)
+	if err != nil {
+		return models.Item{}, 0, err
+	}
+	if err := checkVersion(order, version); err != nil {
+		return models.Item{}, 0, err
+	}
+	i, err := itemIndex(order, itemID)
+	if err != nil {
+		return models.Item{}, 0, err
+	}
+	item := order.Items[i]
+	if err := update(&item); err != nil {
+		return models.Item{}, 0, err
+	}
+	item.ID = itemID
+	item.OrderID = orderID
+	item.TenantID = order.TenantID
+	if err := item.BeforeSave(nil); err != nil {
+		return models.Item{}, 0, err
+	}
+	order = copyOrder(order)
+	order.Items[i] = item
+	order.Version++
+	s.orders[orderID] = order
+	return item, order.Version, nil
+}
+
+func (s *MemoryStore) DeleteItem(orderID, itemID, version uint) (uint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	order, err := s.liveOrder(orderID)
+	if err != nil {
+		return 0, err
+	}
+	if err := checkVersion(order, version); err != nil {
+		return 0, err
+	}
+	i, err := itemIndex(order, itemID)
+	if err != nil {
+		return 0, err
+	}
+	order = copyOrder(order)
+	order.Items = append(order.Items[:i], order.Items[i+1:]...)
+	order.Version++
+	s.orders[orderID] = order
+	return order.Version, nil
+}
+
+func (s *MemoryStore) DeleteOrderById(id uint, version uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	order, ok := s.order(id)
+	if !ok || order.DeletedAt.Valid {
+		return ErrRecordNotFound
+	}
+	if err := checkVersion(order, version); err != nil {
+		return err
+	}
+	order.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	s.orders[id] = order
+	return nil
+}
+
+func (s *MemoryStore) RestoreOrderById(id uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	order, ok := s.order(id)
+	if !ok {
+		return ErrRecordNotFound
+	}
+	if !order.DeletedAt.Valid {
+		return ErrNotDeleted
+	}
+	order.DeletedAt = gorm.DeletedAt{}
+	order.Version++
+	s.orders[id] = order
+	return nil
+}
+
+func (s *MemoryStore) PurgeOrderById(id uint, version uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	order, ok := s.order(id)
+	if !ok {
+		return ErrRecordNotFound
+	}
+	if err := checkVersion(order, version); err != nil {
+		return err
+	}
+	delete(s.orders, id)
+	return nil
+}
+
+func (s *MemoryStore) PurgeDeletedBefore(t time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var purged int64
+	for id, order := range s.orders {
+		if order.DeletedAt.Valid && order.DeletedAt.Time.Before(t) && s.scope.sees(order) {
+			delete(s.orders, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+func (f OrderFilter) matches(order models.Order) bool {
+	if f.CustomerName != "" && order.CustomerName != f.CustomerName {
+		return false
+	}
+	if f.CustomerNamePrefix != "" && !strings.HasPrefix(order.CustomerName, f.CustomerNamePrefix) {
+		return false
+	}
+	if f.OrderedFrom != nil && order.OrderedAt.Before(*f.OrderedFrom) {
+		return false
+	}
+	if f.OrderedTo != nil && order.OrderedAt.After(*f.OrderedTo) {
+		return false
+	}
+	if len(f.ItemCodes) > 0 {
+		for _, item := range order.Items {
+			for _, code := range f.ItemCodes {
+				if item.ItemCode == code {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// compareOrders orders a and b by column sortBy, then by id.
+func compareOrders(a, b models.Order, sortBy string) int {
+	switch sortBy {
+	case "customer_name":
+		if c := strings.Compare(a.CustomerName, b.CustomerName); c != 0 {
+			return c
+		}
+	case "ordered_at":
+		if a.OrderedAt.Before(b.OrderedAt) {
+			return -1
+		}
+		if a.OrderedAt.After(b.OrderedAt) {
+			return 1
+		}
+	}
+	switch {
+	case a.ID < b.ID:
+		return -1
+	case a.ID > b.ID:
+		return 1
+	}
+	return 0
+}
+
+func (s *MemoryStore) ListOrders(q OrderListQuery) (OrderPage, error) {
+	page := OrderPage{}
+	if err := q.normalize(); err != nil {
+		return page, err
+	}
+	desc := q.SortDesc
+	backward := q.Cursor != nil && q.Cursor.Before
+	if backward {
+		desc = !desc
+	}
+	var cursorOrder models.Order
+	if q.Cursor != nil {
+		value, err := cursorValue(q.Cursor, q.SortBy)
+		if err != nil {
+			return page, err
+		}
+		cursorOrder.ID = q.Cursor.ID
+		switch v := value.(type) {
+		case string:
+			cursorOrder.CustomerName = v
+		case time.Time:
+			cursorOrder.OrderedAt = v
+		}
+	}
+
+	s.mu.RLock()
+	var matched []models.Order
+	for _, order := range s.orders {
+		if !order.DeletedAt.Valid && s.scope.sees(order) && q.OrderFilter.matches(order) {
+			matched = append(matched, copyOrder(order))
+		}
+	}
+	s.mu.RUnlock()
+	page.Total = int64(len(matched))
+
+	sort.Slice(matched, func(i, j int) bool {
+		c := compareOrders(matched[i], matched[j], q.SortBy)
+		if desc {
+			return c > 0
+		}
+		return c < 0
+	})
+
+	if q.Cursor == nil {
+		if q.Offset < len(matched) {
+			matched = matched[q.Offset:]
+		} else {
+			matched = nil
+		}
+		if len(matched) > q.Limit {
+			matched = matched[:q.Limit]
+		}
+		page.Orders = matched
+		page.HasPrev = q.Offset > 0
+		page.HasNext = int64(q.Offset+len(page.Orders)) < page.Total
+		return page, nil
+	}
+
+	start := sort.Search(len(matched), func(i int) bool {
+		c := compareOrders(matched[i], cursorOrder, q.SortBy)
+		if desc {
+			return c < 0
+		}
+		return c > 0
+	})
+	matched = matched[start:]
+	if len(matched) > q.Limit+1 {
+		matched = matched[:q.Limit+1]
+	}
+	page.Orders = matched
+	page.finishCursorPage(q.Limit, backward)
+	return page, nil
+}
+
+func (s *MemoryStore) GetIdempotencyKey(key string, since time.Time) (IdempotencyKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	record, ok := s.idempotencyKeys[key]
+	if !ok || record.CreatedAt.Before(since) {
+		return IdempotencyKey{}, ErrRecordNotFound
+	}
+	return record, nil
+}
+
+func (s *MemoryStore) CreateOrderOnce(order *models.Order, record *IdempotencyKey, since time.Time, respond func(order *models.Order) ([]byte, error)) error {
+	var zero time.Time
+	if order.OrderedAt == zero {
+		order.OrderedAt = time.Now()
+	}
+	if err := order.BeforeCreate(nil); err != nil {
+		return err
+	}
+	s.scope.stamp(order)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existing, ok := s.idempotencyKeys[record.Key]; ok && !existing.CreatedAt.Before(since) {
+		return ErrIdempotencyKeyUsed
+	}
+	if err := s.createOrder(order); err != nil {
+		return err
+	}
+	response, err := respond(order)
+	if err != nil {
+		delete(s.orders, order.ID)
+		return err
+	}
+	record.Response = response
+	record.OrderVersion = order.Version
+	record.CreatedAt = time.Now()
+	s.idempotencyKeys[record.Key] = *record
+	return nil
+}
+
+func (s *MemoryStore) PurgeIdempotencyKeysBefore(t time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var purged int64
+	for key, record := range s.idempotencyKeys {
+		if record.CreatedAt.Before(t) {
+			delete(s.idempotencyKeys, key)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+func (s *MemoryStore) CreateAPIKey(key *APIKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, existing := range s.apiKeys {
+		if existing.Hash == key.Hash {
+			return ErrDuplicateKey
+		}
+	}
+	if s.scope.Tenant != "" {
+		key.TenantID = s.scope.Tenant
+	}
+	key.ID = uint(len(s.apiKeys)) + 1
+	key.CreatedAt = time.Now()
+	s.apiKeys = append(s.apiKeys, *key)
+	return nil
+}
+
+func (s *MemoryStore) GetAPIKeyByHash(hash string) (APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, key := range s.apiKeys {
+		if key.Hash == hash && key.RevokedAt == nil {
+			return key, nil
+		}
+	}
+	return APIKey{}, ErrAPIKeyNotFound
+}
+
+func (s *MemoryStore) ListAPIKeys() ([]APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var keys []APIKey
+	for _, key := range s.apiKeys {
+		if s.scope.seesKey(key) {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+func (s *MemoryStore) RevokeAPIKey(id uint) (APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if id == 0 || id > uint(len(s.apiKeys)) || !s.scope.seesKey(s.apiKeys[id-1]) {
+		return APIKey{}, ErrAPIKeyNotFound
+	}
+	key := &s.apiKeys[id-1]
+	if key.RevokedAt == nil {
+		now := time.Now()
+		key.RevokedAt = &now
+	}
+	return *key, nil
+}
@@ -0,0 +1,309 @@
+package database
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// migrationFiles holds one directory of NNNN_name.up.sql/NNNN_name.down.sql
+// pairs per dialect.
+//
+//go:embed migrations
+var migrationFiles embed.FS
+
+var ErrSchemaBehind error = errors.New("database schema is behind, run the migrate up command")
+
+type Migration struct {
+	Version uint
+	Name    string
+	Up      string
+	Down    string
+}
+
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// schemaMigration is a row of schema_migrations, one per applied migration.
+type schemaMigration struct {
+	Version   uint `gorm:"primaryKey;autoIncrement:false"`
+	Name      string
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// SchemaStore is implemented by the stores backed by a SQL schema.
+type SchemaStore interface {
+	DB() *gorm.DB
+}
+
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+// NewMigrator loads the embedded migrations for the dialect of db.
+func NewMigrator(db *gorm.DB) (*Migrator, error) {
+	dir := path.Join("migrations", db.Dialector.Name())
+	entries, err := fs.ReadDir(migrationFiles, dir)
+	if err != nil {
+		return nil, fmt.Errorf("no migrations for dialect %s", db.Dialector.Name())
+	}
+	byVersion := map[uint]*Migration{}
+	for _, entry := range entries {
+		name := entry.Name()
+		var direction string
+		switch {
+		case strings.HasSuffix(name, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(name, ".down.sql"):
+			direction = "down"
+		default:
+			continue
+		}
+		base := strings.TrimSuffix(name, "."+direction+".sql")
+		prefix, title, _ := strings.Cut(base, "_")
+		version, err := strconv.ParseUint(prefix, 10, 0)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: version prefix: %w", name, err)
+		}
+		sql, err := fs.ReadFile(migrationFiles, path.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		m, ok := byVersion[uint(version)]
+		if !ok {
+			m = &Migration{Version: uint(version), Name: title}
+			byVersion[uint(version)] = m
+		}
+		if direction == "up" {
+			m.Up = string(sql)
+		} else {
+			m.Down = string(sql)
+		}
+	}
+	migrator := &Migrator{db: db}
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %04d_%s lacks its up or down file", m.Version, m.Name)
+		}
+		migrator.migrations = append(migrator.migrations, *m)
+	}
+	sort.Slice(migrator.migrations, func(i, j int) bool {
+		return migrator.migrations[i].Version < migrator.migrations[j].Version
+	})
+	return migrator, nil
+}
+
+// Latest returns the version of the newest embedded migration.
+func (m *Migrator) Latest() uint {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+func (m *Migrator) applied() (map[uint]schemaMigration, error) {
+	if err := m.db.AutoMigrate(&schemaMigration{}); err != nil {
+		return nil, err
+	}
+	var rows []schemaMigration
+	if err := m.db.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	applied := make(map[uint]schemaMigration, len(rows))
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+	return applied, nil
+}
+
+// Current returns the version of the newest applied migration, 0 for an empty database.
+func (m *Migrator) Current() (uint, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return 0, err
+	}
+	var current uint
+	for version := range applied {
+		if version > current {
+			current = version
+		}
+	}
+	return current, nil
+}
+
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]MigrationStatus, len(m.migrations))
+	for i, migration := range m.migrations {
+		statuses[i].Migration = migration
+		if row, ok := applied[migration.Version]; ok {
+			appliedAt := row.AppliedAt
+			statuses[i].AppliedAt = &appliedAt
+		}
+	}
+	return statuses, nil
+}
+
+// Pending returns the migrations that have not been applied yet.
+func (m *Migrator) Pending() ([]Migration, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; !ok {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// CheckCurrent returns ErrSchemaBehind when a migration is pending.
+func (m *Migrator) CheckCurrent() error {
+	pending, err := m.Pending()
+	if err != nil {
+		return err
+	}
+	return m.behind(len(pending))
+}
+
+// Check is CheckCurrent for the readiness probe: it only reads
+// schema_migrations, in ctx, and fails when the table is missing instead of
+// creating it.
+func (m *Migrator) Check(ctx context.Context) error {
+	var versions []uint
+	if err := m.db.WithContext(ctx).Model(&schemaMigration{}).Pluck("version", &versions).Error; err != nil {
+		return err
+	}
+	applied := make(map[uint]bool, len(versions))
+	for _, version := range versions {
+		applied[version] = true
+	}
+	pending := 0
+	for _, migration := range m.migrations {
+		if !applied[migration.Version] {
+			pending++
+		}
+	}
+	return m.behind(pending)
+}
+
+// behind returns ErrSchemaBehind when migrations are pending.
+func (m *Migrator) behind(pending int) error {
+	if pending > 0 {
+		return fmt.Errorf("%w: %d pending, latest is %04d", ErrSchemaBehind, pending, m.Latest())
+	}
+	return nil
+}
+
+// Up applies every pending migration.
+func (m *Migrator) Up() ([]Migration, error) {
+	return m.To(m.Latest())
+}
+
+// Down reverts the newest applied migration.
+func (m *Migrator) Down() ([]Migration, error) {
+	current, err := m.Current()
+	if err != nil || current == 0 {
+		return nil, err
+	}
+	target := uint(0)
+	for _, migration := range m.migrations {
+		if migration.Version < current {
+			target = migration.Version
+		}
+	}
+	return m.To(target)
+}
+
+// To applies or reverts migrations until version is the newest applied one.
+// Version 0 reverts every migration. It returns the migrations it ran.
+func (m *Migrator) To(version uint) ([]Migration, error) {
+	if version != 0 && !m.known(version) {
+		return nil, fmt.Errorf("unknown migration version %d", version)
+	}
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	var ran []Migration
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; ok || migration.Version > version {
+			continue
+		}
+		if err := m.run(migration, true); err != nil {
+			return ran, err
+		}
+		ran = append(ran, migration)
+	}
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		migration := m.migrations[i]
+		if _, ok := applied[migration.Version]; !ok || migration.Version <= version {
+			continue
+		}
+		if err := m.run(migration, false); err != nil {
+			return ran, err
+		}
+		ran = append(ran, migration)
+	}
+	return ran, nil
+}
+
+func (m *Migrator) known(version uint) bool {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Migrator) run(migration Migration, up bool) error {
+	err := m.db.Transaction(func(tx *gorm.DB) error {
+		// The migrations see the rows of every tenant through the row level
+		// security policies of postgres.
+		if m.db.Dialector.Name() == "postgres" {
+			if err := tx.Exec("SELECT set_config('app.bypass_rls', 'on', true)").Error; err != nil {
+				return err
+			}
+		}
+		if up {
+			if err := tx.Exec(migration.Up).Error; err != nil {
+				return err
+			}
+			return tx.Create(&schemaMigration{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now()}).Error
+		}
+		if err := tx.Exec(migration.Down).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&schemaMigration{}, migration.Version).Error
+	})
+	if err != nil {
+		direction := "down"
+		if up {
+			direction = "up"
+		}
+		return fmt.Errorf("migration %04d_%s %s: %w", migration.Version, migration.Name, direction, err)
+	}
+	return nil
+}
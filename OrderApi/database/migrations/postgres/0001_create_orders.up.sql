@@ -0,0 +1,16 @@
+-- Matches the schema AutoMigrate used to create, so existing databases are
+-- adopted as they are.
+CREATE TABLE IF NOT EXISTS orders (
+    id BIGSERIAL PRIMARY KEY,
+    customer_name VARCHAR(8192),
+    ordered_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS items (
+    id BIGSERIAL PRIMARY KEY,
+    item_code VARCHAR(8192) NOT NULL,
+    description VARCHAR(8192),
+    quantity BIGINT NOT NULL,
+    order_id BIGINT,
+    CONSTRAINT fk_orders_items FOREIGN KEY (order_id) REFERENCES orders (id)
+);
@@ -0,0 +1,2 @@
+-- The deleted orphan items cannot be restored.
+SELECT 1;
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_orders_ordered_at;
+DROP INDEX IF EXISTS idx_items_item_code;
+DROP INDEX IF EXISTS idx_items_order_id;
+
+ALTER TABLE items DROP CONSTRAINT IF EXISTS fk_orders_items;
+ALTER TABLE items ADD CONSTRAINT fk_orders_items
+    FOREIGN KEY (order_id) REFERENCES orders (id);
+ALTER TABLE items ALTER COLUMN order_id DROP NOT NULL;
@@ -0,0 +1,8 @@
+ALTER TABLE items ALTER COLUMN order_id SET NOT NULL;
+ALTER TABLE items DROP CONSTRAINT IF EXISTS fk_orders_items;
+ALTER TABLE items ADD CONSTRAINT fk_orders_items
+    FOREIGN KEY (order_id) REFERENCES orders (id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_items_order_id ON items (order_id);
+CREATE INDEX IF NOT EXISTS idx_items_item_code ON items (item_code);
+CREATE INDEX IF NOT EXISTS idx_orders_ordered_at ON orders (ordered_at);
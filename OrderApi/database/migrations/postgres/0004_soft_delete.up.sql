@@ -0,0 +1,5 @@
+ALTER TABLE orders ADD COLUMN deleted_at TIMESTAMPTZ;
+ALTER TABLE items ADD COLUMN deleted_at TIMESTAMPTZ;
+
+CREATE INDEX idx_orders_deleted_at ON orders (deleted_at);
+CREATE INDEX idx_items_deleted_at ON items (deleted_at);
@@ -0,0 +1,9 @@
+CREATE TABLE idempotency_keys (
+    key VARCHAR(255) PRIMARY KEY,
+    request_hash VARCHAR(64) NOT NULL,
+    status_code BIGINT NOT NULL,
+    response BYTEA,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_idempotency_keys_created_at ON idempotency_keys (created_at);
@@ -0,0 +1,11 @@
+CREATE TABLE api_keys (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    prefix VARCHAR(16) NOT NULL,
+    hash VARCHAR(64) NOT NULL,
+    roles VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX idx_api_keys_hash ON api_keys (hash);
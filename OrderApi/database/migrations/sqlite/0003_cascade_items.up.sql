@@ -0,0 +1,17 @@
+-- sqlite cannot alter a constraint, so items is rebuilt.
+CREATE TABLE items_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    item_code TEXT NOT NULL,
+    description TEXT,
+    quantity INTEGER NOT NULL,
+    order_id INTEGER NOT NULL,
+    CONSTRAINT fk_orders_items FOREIGN KEY (order_id) REFERENCES orders (id) ON DELETE CASCADE
+);
+INSERT INTO items_new (id, item_code, description, quantity, order_id)
+    SELECT id, item_code, description, quantity, order_id FROM items;
+DROP TABLE items;
+ALTER TABLE items_new RENAME TO items;
+
+CREATE INDEX idx_items_order_id ON items (order_id);
+CREATE INDEX idx_items_item_code ON items (item_code);
+CREATE INDEX idx_orders_ordered_at ON orders (ordered_at);
@@ -0,0 +1,9 @@
+-- Soft deleted rows would reappear, remove them for good.
+DELETE FROM orders WHERE deleted_at IS NOT NULL;
+DELETE FROM items WHERE deleted_at IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_items_deleted_at;
+DROP INDEX IF EXISTS idx_orders_deleted_at;
+
+ALTER TABLE items DROP COLUMN deleted_at;
+ALTER TABLE orders DROP COLUMN deleted_at;
@@ -0,0 +1,9 @@
+CREATE TABLE idempotency_keys (
+    key TEXT PRIMARY KEY,
+    request_hash TEXT NOT NULL,
+    status_code INTEGER NOT NULL,
+    response BLOB,
+    created_at DATETIME NOT NULL
+);
+
+CREATE INDEX idx_idempotency_keys_created_at ON idempotency_keys (created_at);
@@ -0,0 +1,11 @@
+CREATE TABLE api_keys (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    prefix TEXT NOT NULL,
+    hash TEXT NOT NULL,
+    roles TEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    revoked_at DATETIME
+);
+
+CREATE UNIQUE INDEX idx_api_keys_hash ON api_keys (hash);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_orders_owner;
+
+ALTER TABLE orders DROP COLUMN owner;
@@ -0,0 +1,5 @@
+-- Orders made before ownership belong to nobody, only support staff and
+-- admins see them.
+ALTER TABLE orders ADD COLUMN owner TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX idx_orders_owner ON orders (owner);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_api_keys_tenant_id;
+DROP INDEX IF EXISTS idx_items_tenant_id;
+DROP INDEX IF EXISTS idx_orders_tenant_id;
+
+ALTER TABLE api_keys DROP COLUMN tenant_id;
+ALTER TABLE items DROP COLUMN tenant_id;
+ALTER TABLE orders DROP COLUMN tenant_id;
@@ -0,0 +1,11 @@
+-- Rows made before tenancy belong to the empty tenant, which no request
+-- resolves to. Move them to a tenant before enabling tenancy.
+ALTER TABLE orders ADD COLUMN tenant_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE items ADD COLUMN tenant_id TEXT NOT NULL DEFAULT '';
+-- An API key of a tenant only reaches the orders of that tenant, the empty
+-- tenant is the whole server.
+ALTER TABLE api_keys ADD COLUMN tenant_id TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX idx_orders_tenant_id ON orders (tenant_id);
+CREATE INDEX idx_items_tenant_id ON items (tenant_id);
+CREATE INDEX idx_api_keys_tenant_id ON api_keys (tenant_id);
@@ -0,0 +1,2 @@
+-- The offsets the times were saved with are gone, they stay in UTC.
+SELECT 1;
@@ -0,0 +1,6 @@
+-- sqlite compares ordered_at as text, so the times saved with the offset of
+-- the client sorted and filtered wrong. They are rewritten in UTC, in the
+-- format of the driver; strftime keeps milliseconds only.
+UPDATE orders
+SET ordered_at = rtrim(rtrim(strftime('%Y-%m-%d %H:%M:%f', ordered_at), '0'), '.') || '+00:00'
+WHERE ordered_at NOT LIKE '%+00:00';
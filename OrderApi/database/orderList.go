@@ -0,0 +1,233 @@
+package database
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"assignment2.id/orderapi/models"
+	"gorm.io/gorm"
+)
+
+var ErrInvalidSort error = errors.New("Kolom sort tidak dikenal.")
+var ErrInvalidCursor error = errors.New("Cursor tidak valid.")
+
+// sortColumns maps every accepted sort key, both the snake_case column name
+// and the JSON field name, to its column in the orders table.
+var sortColumns = map[string]string{
+	"id":            "id",
+	"customer_name": "customer_name",
+	"customername":  "customer_name",
+	"ordered_at":    "ordered_at",
+	"orderedat":     "ordered_at",
+}
+
+// SortColumn resolves a user supplied sort key to a column of the orders table.
+func SortColumn(key string) (string, error) {
+	column, ok := sortColumns[strings.ToLower(key)]
+	if !ok {
+		return "", ErrInvalidSort
+	}
+	return column, nil
+}
+
+type OrderFilter struct {
+	CustomerName       string
+	CustomerNamePrefix string
+	OrderedFrom        *time.Time
+	OrderedTo          *time.Time
+	ItemCodes          []string
+}
+
+// OrderCursor marks a position in a sorted order listing. Value holds the
+// sort column of the order at that position, ID breaks ties between equal values.
+type OrderCursor struct {
+	Value  string `json:"v"`
+	ID     uint   `json:"id"`
+	Before bool   `json:"b,omitempty"`
+}
+
+type OrderListQuery struct {
+	OrderFilter
+	SortBy   string
+	SortDesc bool
+	Limit    int
+	Offset   int
+	Cursor   *OrderCursor
+}
+
+type OrderPage struct {
+	Orders  []models.Order
+	Total   int64
+	HasNext bool
+	HasPrev bool
+}
+
+func EncodeCursor(c OrderCursor) string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func DecodeCursor(s string) (*OrderCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c OrderCursor
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// CursorFor returns the cursor pointing at order when listing by column sortBy.
+func CursorFor(order models.Order, sortBy string, before bool) OrderCursor {
+	c := OrderCursor{ID: order.ID, Before: before}
+	switch sortBy {
+	case "customer_name":
+		c.Value = order.CustomerName
+	case "ordered_at":
+		c.Value = order.OrderedAt.UTC().Format(time.RFC3339Nano)
+	default:
+		c.Value = strconv.FormatUint(uint64(order.ID), 10)
+	}
+	return c
+}
+
+func cursorValue(c *OrderCursor, sortBy string) (interface{}, error) {
+	switch sortBy {
+	case "customer_name":
+		return c.Value, nil
+	case "ordered_at":
+		t, err := time.Parse(time.RFC3339Nano, c.Value)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		// Compared with the times stored in UTC.
+		return t.UTC(), nil
+	default:
+		return c.ID, nil
+	}
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+func (s *GormStore) applyOrderFilter(tx *gorm.DB, f OrderFilter) *gorm.DB {
+	tx = tx.Scopes(s.owned)
+	if f.CustomerName != "" {
+		tx = tx.Where("customer_name = ?", f.CustomerName)
+	}
+	if f.CustomerNamePrefix != "" {
+		tx = tx.Where(`customer_name LIKE ? ESCAPE '\'`, escapeLike(f.CustomerNamePrefix)+"%")
+	}
+	if f.OrderedFrom != nil {
+		tx = tx.Where("ordered_at >= ?", f.OrderedFrom.UTC())
+	}
+	if f.OrderedTo != nil {
+		tx = tx.Where("ordered_at <= ?", f.OrderedTo.UTC())
+	}
+	if len(f.ItemCodes) > 0 {
+		tx = tx.Where("id IN (?)", s.db.Model(&models.Item{}).Scopes(s.tenanted).Select("order_id").Where("item_code IN ?", f.ItemCodes))
+	}
+	return tx
+}
+
+// ListOrders returns one page of orders matching q together with the total
+// number of matching orders. A non-nil q.Cursor selects keyset pagination,
+// otherwise q.Offset is used.
+func (s *GormStore) ListOrders(q OrderListQuery) (OrderPage, error) {
+	if err := q.normalize(); err != nil {
+		return OrderPage{}, err
+	}
+	var page OrderPage
+	err := s.session(func(db *gorm.DB) error {
+		var err error
+		page, err = s.listOrders(db, q)
+		return err
+	})
+	return page, err
+}
+
+func (s *GormStore) listOrders(db *gorm.DB, q OrderListQuery) (OrderPage, error) {
+	page := OrderPage{}
+	if err := s.applyOrderFilter(db.Model(&models.Order{}), q.OrderFilter).Count(&page.Total).Error; err != nil {
+		return page, err
+	}
+
+	desc := q.SortDesc
+	backward := q.Cursor != nil && q.Cursor.Before
+	if backward {
+		desc = !desc
+	}
+	direction := "ASC"
+	cmp := ">"
+	if desc {
+		direction = "DESC"
+		cmp = "<"
+	}
+	tx := s.applyOrderFilter(db.Model(&models.Order{}), q.OrderFilter).Preload("Items")
+	if q.SortBy == "id" {
+		tx = tx.Order("id " + direction)
+	} else {
+		tx = tx.Order(q.SortBy + " " + direction).Order("id " + direction)
+	}
+
+	if q.Cursor == nil {
+		if err := tx.Offset(q.Offset).Limit(q.Limit).Find(&page.Orders).Error; err != nil {
+			return page, err
+		}
+		page.HasPrev = q.Offset > 0
+		page.HasNext = int64(q.Offset+len(page.Orders)) < page.Total
+		return page, nil
+	}
+
+	value, err := cursorValue(q.Cursor, q.SortBy)
+	if err != nil {
+		return page, err
+	}
+	if q.SortBy == "id" {
+		tx = tx.Where(fmt.Sprintf("id %s ?", cmp), value)
+	} else {
+		tx = tx.Where(fmt.Sprintf("(%[1]s %[2]s ? OR (%[1]s = ? AND id %[2]s ?))", q.SortBy, cmp), value, value, q.Cursor.ID)
+	}
+	if err := tx.Limit(q.Limit + 1).Find(&page.Orders).Error; err != nil {
+		return page, err
+	}
+	page.finishCursorPage(q.Limit, backward)
+	return page, nil
+}
+
+func (q *OrderListQuery) normalize() error {
+	if q.SortBy == "" {
+		q.SortBy = "id"
+	}
+	if _, ok := sortColumns[q.SortBy]; !ok {
+		return ErrInvalidSort
+	}
+	return nil
+}
+
+// finishCursorPage trims the extra order fetched to detect a following page
+// and, for a backward cursor, restores the requested sort order.
+func (page *OrderPage) finishCursorPage(limit int, backward bool) {
+	more := len(page.Orders) > limit
+	if more {
+		page.Orders = page.Orders[:limit]
+	}
+	if backward {
+		for i, j := 0, len(page.Orders)-1; i < j; i, j = i+1, j-1 {
+			page.Orders[i], page.Orders[j] = page.Orders[j], page.Orders[i]
+		}
+		page.HasPrev = more
+		page.HasNext = true
+	} else {
+		page.HasPrev = true
+		page.HasNext = more
+	}
+}
@@ -0,0 +1,46 @@
+package database
+
+import (
+	"log/slog"
+	"time"
+)
+
+// RunPurger permanently deletes, every interval, the orders soft deleted
+// longer than retention ago. It returns once stop is closed.
+func RunPurger(store OrderStore, retention, interval time.Duration, stop <-chan struct{}) {
+	runEvery(interval, stop, func() {
+		purged, err := store.PurgeDeletedBefore(time.Now().Add(-retention))
+		if err != nil {
+			slog.Error("purging deleted orders failed", "err", err)
+		} else if purged > 0 {
+			slog.Info("deleted orders purged", "count", purged)
+		}
+	})
+}
+
+// RunIdempotencyPurger deletes, every interval, the idempotency keys older
+// than ttl. It returns once stop is closed.
+func RunIdempotencyPurger(store OrderStore, ttl, interval time.Duration, stop <-chan struct{}) {
+	runEvery(interval, stop, func() {
+		purged, err := store.PurgeIdempotencyKeysBefore(time.Now().Add(-ttl))
+		if err != nil {
+			slog.Error("purging idempotency keys failed", "err", err)
+		} else if purged > 0 {
+			slog.Info("expired idempotency keys purged", "count", purged)
+		}
+	})
+}
+
+// runEvery runs job now and then every interval until stop is closed.
+func runEvery(interval time.Duration, stop <-chan struct{}, job func()) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		job()
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package database
+
+import (
+	"assignment2.id/orderapi/models"
+	"gorm.io/gorm"
+)
+
+// Scope limits the orders an OrderStore returned by Scoped sees. Orders out
+// of scope are reported missing, so their existence is not revealed. The
+// zero Scope sees every order.
+type Scope struct {
+	// Owner, when set, limits the store to the orders of that owner.
+	Owner string
+	// Tenant, when set, limits the store to the orders, items and API keys
+	// of that tenant, and puts the orders and API keys it creates in it.
+	Tenant string
+}
+
+func (sc Scope) sees(order models.Order) bool {
+	return (sc.Owner == "" || order.Owner == sc.Owner) && (sc.Tenant == "" || order.TenantID == sc.Tenant)
+}
+
+// seesKey reports whether key is in the tenant of the scope.
+func (sc Scope) seesKey(key APIKey) bool {
+	return sc.Tenant == "" || key.TenantID == sc.Tenant
+}
+
+// stamp puts a new order, and its items, in the tenant of the scope.
+func (sc Scope) stamp(order *models.Order) {
+	if sc.Tenant != "" {
+		order.TenantID = sc.Tenant
+	}
+	for i := range order.Items {
+		order.Items[i].TenantID = order.TenantID
+	}
+}
+
+func (s *GormStore) Scoped(scope Scope) OrderStore {
+	return &GormStore{db: s.db, scope: scope, rls: s.rls}
+}
+
+// owned limits a query on the orders table to the scope of the store.
+func (s *GormStore) owned(tx *gorm.DB) *gorm.DB {
+	if s.scope.Owner != "" {
+		tx = tx.Where("orders.owner = ?", s.scope.Owner)
+	}
+	if s.scope.Tenant != "" {
+		tx = tx.Where("orders.tenant_id = ?", s.scope.Tenant)
+	}
+	return tx
+}
+
+// tenanted limits a query on the items or the api_keys table to the tenant
+// of the store.
+func (s *GormStore) tenanted(tx *gorm.DB) *gorm.DB {
+	if s.scope.Tenant != "" {
+		tx = tx.Where("tenant_id = ?", s.scope.Tenant)
+	}
+	return tx
+}
+
+// transaction runs fn in a transaction. In row level security mode the
+// transaction of a tenant sets app.tenant_id, so postgres hides the rows of
+// the other tenants as well, and the transaction of no tenant sets
+// app.bypass_rls, without which postgres hides every row.
+func (s *GormStore) transaction(fn func(tx *gorm.DB) error) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if s.rls {
+			setting, value := "app.tenant_id", s.scope.Tenant
+			if s.scope.Tenant == "" {
+				setting, value = "app.bypass_rls", "on"
+			}
+			if err := tx.Exec("SELECT set_config(?, ?, true)", setting, value).Error; err != nil {
+				return err
+			}
+		}
+		return fn(tx)
+	})
+}
+
+// session runs fn, a read or a single statement, in a transaction only when
+// the row level security mode needs one for its settings.
+func (s *GormStore) session(fn func(db *gorm.DB) error) error {
+	if !s.rls {
+		return fn(s.db)
+	}
+	return s.transaction(fn)
+}
+
+func (s *MemoryStore) Scoped(scope Scope) OrderStore {
+	return &MemoryStore{memoryState: s.memoryState, scope: scope}
+}
+
+// order returns the order with id, soft deleted or not, when the scope of
+// the store sees it. s.mu must be held.
+func (s *MemoryStore) order(id uint) (models.Order, bool) {
+	order, ok := s.orders[id]
+	return order, ok && s.scope.sees(order)
+}
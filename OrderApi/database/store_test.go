@@ -0,0 +1,488 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"assignment2.id/orderapi/models"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// postgresDSNEnv names the environment variable holding the DSN of a
+// postgres database to run the contract of OrderStore against. Its orders,
+// items and idempotency keys are deleted.
+const postgresDSNEnv = "ORDERAPI_TEST_POSTGRES_DSN"
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, func() OrderStore {
+		return NewMemoryStore()
+	})
+}
+
+func TestSQLiteStore(t *testing.T) {
+	testStore(t, func() OrderStore {
+		store, err := NewSQLiteStore(":memory:", false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { store.Close() })
+		migrate(t, store)
+		return store
+	})
+}
+
+func TestPostgresStore(t *testing.T) {
+	dsn := os.Getenv(postgresDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", postgresDSNEnv)
+	}
+	testStore(t, func() OrderStore {
+		store, err := openGorm(postgres.Open(dsn), false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { store.Close() })
+		// Without a tenant, the transactions of the store bypass the row
+		// level security policies, which hide every row otherwise.
+		store.rls = true
+		migrate(t, store)
+		err = store.transaction(func(tx *gorm.DB) error {
+			return tx.Exec("TRUNCATE orders, items, idempotency_keys RESTART IDENTITY").Error
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return store
+	})
+}
+
+func migrate(t *testing.T, store *GormStore) {
+	t.Helper()
+	migrator, err := NewMigrator(store.DB())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// testStore checks the contract of OrderStore shared by every backend.
+// newStore returns an empty store, it is called once per subtest.
+func testStore(t *testing.T, newStore func() OrderStore) {
+	t.Run("CreateAndGet", func(t *testing.T) {
+		store := newStore()
+		order := newOrder("A", "X", "Y")
+		if err := store.CreateOrder(&order); err != nil {
+			t.Fatal(err)
+		}
+		if order.ID == 0 || order.Version != 1 {
+			t.Fatalf("created order has ID %d and version %d, want an ID and version 1", order.ID, order.Version)
+		}
+		for _, item := range order.Items {
+			if item.ID == 0 || item.OrderID != order.ID {
+				t.Fatalf("created item has ID %d and order ID %d, want an ID and order ID %d", item.ID, item.OrderID, order.ID)
+			}
+		}
+		got, err := store.GetOrderById(order.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.CustomerName != "A" || got.Version != 1 {
+			t.Errorf("got customer %q at version %d, want %q at version 1", got.CustomerName, got.Version, "A")
+		}
+		checkItems(t, got.Items, order.Items)
+	})
+
+	t.Run("GetMissing", func(t *testing.T) {
+		store := newStore()
+		if _, err := store.GetOrderById(404); !errors.Is(err, ErrRecordNotFound) {
+			t.Errorf("got error %v, want ErrRecordNotFound", err)
+		}
+	})
+
+	t.Run("CreateDuplicateID", func(t *testing.T) {
+		store := newStore()
+		order := newOrder("A", "X")
+		if err := store.CreateOrder(&order); err != nil {
+			t.Fatal(err)
+		}
+		duplicate := newOrder("B")
+		duplicate.ID = order.ID
+		if err := store.CreateOrder(&duplicate); !errors.Is(err, ErrDuplicateKey) {
+			t.Errorf("got error %v, want ErrDuplicateKey", err)
+		}
+		got, err := store.GetOrderById(order.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.CustomerName != "A" {
+			t.Errorf("got customer %q, want the first order kept", got.CustomerName)
+		}
+	})
+
+	t.Run("GetOrderByIds", func(t *testing.T) {
+		store := newStore()
+		a, b := newOrder("A"), newOrder("B")
+		for _, order := range []*models.Order{&a, &b} {
+			if err := store.CreateOrder(order); err != nil {
+				t.Fatal(err)
+			}
+		}
+		orders, missing, err := store.GetOrderByIds(b.ID, 404, a.ID, b.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(orders) != 2 || orders[0].ID != b.ID || orders[1].ID != a.ID {
+			t.Errorf("got orders %v, want %d then %d", orderIDs(orders), b.ID, a.ID)
+		}
+		if len(missing) != 1 || missing[0] != 404 {
+			t.Errorf("got missing %v, want [404]", missing)
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		store := newStore()
+		order := newOrder("A", "X", "Y")
+		if err := store.CreateOrder(&order); err != nil {
+			t.Fatal(err)
+		}
+		update := newOrder("B", "Z")
+		if err := store.UpdateOrderById(order.ID, &update, 1); err != nil {
+			t.Fatal(err)
+		}
+		if update.Version != 2 {
+			t.Errorf("got version %d, want 2", update.Version)
+		}
+		if update.Items[0].ID == 0 || update.Items[0].OrderID != order.ID {
+			t.Errorf("new item has ID %d and order ID %d, want an ID and order ID %d", update.Items[0].ID, update.Items[0].OrderID, order.ID)
+		}
+		got, err := store.GetOrderById(order.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.CustomerName != "B" || got.Version != 2 {
+			t.Errorf("got customer %q at version %d, want %q at version 2", got.CustomerName, got.Version, "B")
+		}
+		checkItems(t, got.Items, update.Items)
+	})
+
+	t.Run("UpdateStaleVersion", func(t *testing.T) {
+		store := newStore()
+		order := newOrder("A", "X")
+		if err := store.CreateOrder(&order); err != nil {
+			t.Fatal(err)
+		}
+		update := newOrder("B")
+		if err := store.UpdateOrderById(order.ID, &update, 2); !errors.Is(err, ErrVersionMismatch) {
+			t.Errorf("got error %v, want ErrVersionMismatch", err)
+		}
+		got, err := store.GetOrderById(order.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.CustomerName != "A" || got.Version != 1 {
+			t.Errorf("got customer %q at version %d, want the order unchanged", got.CustomerName, got.Version)
+		}
+	})
+
+	t.Run("UpdateMissing", func(t *testing.T) {
+		store := newStore()
+		update := newOrder("B")
+		if err := store.UpdateOrderById(404, &update, 0); !errors.Is(err, ErrRecordNotFound) {
+			t.Errorf("got error %v, want ErrRecordNotFound", err)
+		}
+	})
+
+	t.Run("DeleteAndRestore", func(t *testing.T) {
+		store := newStore()
+		order := newOrder("A", "X")
+		if err := store.CreateOrder(&order); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.DeleteOrderById(order.ID, 2); !errors.Is(err, ErrVersionMismatch) {
+			t.Errorf("delete at a stale version: got error %v, want ErrVersionMismatch", err)
+		}
+		if err := store.DeleteOrderById(order.ID, 1); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := store.GetOrderById(order.ID); !errors.Is(err, ErrRecordNotFound) {
+			t.Errorf("get after delete: got error %v, want ErrRecordNotFound", err)
+		}
+		if err := store.DeleteOrderById(order.ID, 0); !errors.Is(err, ErrRecordNotFound) {
+			t.Errorf("second delete: got error %v, want ErrRecordNotFound", err)
+		}
+		if err := store.RestoreOrderById(order.ID); err != nil {
+			t.Fatal(err)
+		}
+		got, err := store.GetOrderById(order.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Version <= 1 {
+			t.Errorf("got version %d after restore, want it bumped", got.Version)
+		}
+		checkItems(t, got.Items, order.Items)
+		if err := store.RestoreOrderById(order.ID); !errors.Is(err, ErrNotDeleted) {
+			t.Errorf("second restore: got error %v, want ErrNotDeleted", err)
+		}
+	})
+
+	t.Run("DeleteMissing", func(t *testing.T) {
+		store := newStore()
+		if err := store.DeleteOrderById(404, 0); !errors.Is(err, ErrRecordNotFound) {
+			t.Errorf("got error %v, want ErrRecordNotFound", err)
+		}
+		if err := store.RestoreOrderById(404); !errors.Is(err, ErrRecordNotFound) {
+			t.Errorf("restore: got error %v, want ErrRecordNotFound", err)
+		}
+	})
+
+	t.Run("ListByOrderedAtAcrossOffsets", func(t *testing.T) {
+		store := newStore()
+		// The same instants written with various offsets, listed in the
+		// order of the instants whatever the offset.
+		jakarta := time.FixedZone("WIB", 7*60*60)
+		cairo := time.FixedZone("EET", 2*60*60)
+		times := []time.Time{
+			time.Date(2022, 1, 1, 12, 0, 0, 0, jakarta), // 05:00 UTC
+			time.Date(2022, 1, 1, 5, 30, 0, 0, cairo),   // 03:30 UTC
+			time.Date(2022, 1, 1, 4, 0, 0, 0, time.UTC),
+			time.Date(2022, 1, 1, 9, 0, 0, 0, jakarta), // 02:00 UTC
+			time.Date(2022, 1, 1, 6, 45, 0, 0, cairo),  // 04:45 UTC
+		}
+		ids := make([]uint, len(times))
+		for i, orderedAt := range times {
+			order := newOrder("A")
+			order.OrderedAt = orderedAt
+			if err := store.CreateOrder(&order); err != nil {
+				t.Fatal(err)
+			}
+			ids[i] = order.ID
+		}
+		want := []uint{ids[3], ids[1], ids[2], ids[4], ids[0]}
+
+		var got []uint
+		q := OrderListQuery{SortBy: "ordered_at", Limit: 2}
+		for page := 0; ; page++ {
+			if page == len(times) {
+				t.Fatalf("still paging after %d pages, got %v", page, got)
+			}
+			result, err := store.ListOrders(q)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, orderIDs(result.Orders)...)
+			if !result.HasNext {
+				break
+			}
+			cursor := CursorFor(result.Orders[len(result.Orders)-1], "ordered_at", false)
+			q.Cursor = &cursor
+		}
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("paged through %v, want %v", got, want)
+		}
+
+		// 11:00 in Jakarta is 04:00 UTC.
+		from := time.Date(2022, 1, 1, 11, 0, 0, 0, jakarta)
+		result, err := store.ListOrders(OrderListQuery{OrderFilter: OrderFilter{OrderedFrom: &from}, SortBy: "ordered_at", Limit: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := orderIDs(result.Orders), want[2:]; fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("ordered from %v: got %v, want %v", from, got, want)
+		}
+	})
+
+	t.Run("ItemWrites", func(t *testing.T) {
+		store := newStore()
+		order := newOrder("A", "X")
+		if err := store.CreateOrder(&order); err != nil {
+			t.Fatal(err)
+		}
+		item := models.Item{ItemCode: "Y", Quantity: 1}
+		if _, err := store.CreateItem(order.ID, &item, 2); !errors.Is(err, ErrVersionMismatch) {
+			t.Errorf("create at a stale version: got error %v, want ErrVersionMismatch", err)
+		}
+		if version, err := store.CreateItem(order.ID, &item, 1); err != nil {
+			t.Fatal(err)
+		} else if version != 2 {
+			t.Errorf("create: got version %d, want 2", version)
+		}
+		if _, _, err := store.UpdateItem(order.ID, 404, 2, func(*models.Item) error { return nil }); !errors.Is(err, ErrItemNotFound) {
+			t.Errorf("update of a missing item: got error %v, want ErrItemNotFound", err)
+		}
+		if version, err := store.DeleteItem(order.ID, item.ID, 0); err != nil {
+			t.Fatal(err)
+		} else if version != 3 {
+			t.Errorf("delete at any version: got version %d, want 3", version)
+		}
+		if _, err := store.DeleteItem(404, item.ID, 0); !errors.Is(err, ErrRecordNotFound) {
+			t.Errorf("delete from a missing order: got error %v, want ErrRecordNotFound", err)
+		}
+		got, err := store.GetOrderById(order.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Version != 3 {
+			t.Errorf("got version %d, want 3 after two item writes", got.Version)
+		}
+		checkItems(t, got.Items, order.Items)
+	})
+
+	t.Run("TenantIsolation", func(t *testing.T) {
+		store := newStore()
+		tenantA := store.Scoped(Scope{Tenant: "a"})
+		tenantB := store.Scoped(Scope{Tenant: "b"})
+		own := newOrder("A", "X")
+		if err := tenantA.CreateOrder(&own); err != nil {
+			t.Fatal(err)
+		}
+		other := newOrder("B", "Y")
+		if err := tenantB.CreateOrder(&other); err != nil {
+			t.Fatal(err)
+		}
+		if own.TenantID != "a" || other.TenantID != "b" {
+			t.Fatalf("got tenants %q and %q, want a and b", own.TenantID, other.TenantID)
+		}
+		itemID := other.Items[0].ID
+
+		if _, err := tenantA.GetOrderById(other.ID); !errors.Is(err, ErrRecordNotFound) {
+			t.Errorf("get: got error %v, want ErrRecordNotFound", err)
+		}
+		orders, missing, err := tenantA.GetOrderByIds(own.ID, other.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fmt.Sprint(orderIDs(orders), missing) != fmt.Sprint([]uint{own.ID}, []uint{other.ID}) {
+			t.Errorf("get by ids: got %v missing %v, want only %d", orderIDs(orders), missing, own.ID)
+		}
+		page, err := tenantA.ListOrders(OrderListQuery{Limit: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fmt.Sprint(orderIDs(page.Orders)) != fmt.Sprint([]uint{own.ID}) || page.Total != 1 {
+			t.Errorf("list: got %v of %d, want only %d", orderIDs(page.Orders), page.Total, own.ID)
+		}
+		if _, err := tenantA.GetItems(other.ID); !errors.Is(err, ErrRecordNotFound) {
+			t.Errorf("get items: got error %v, want ErrRecordNotFound", err)
+		}
+		if _, err := tenantA.GetItem(own.ID, itemID); !errors.Is(err, ErrItemNotFound) {
+			t.Errorf("get an item of the other tenant: got error %v, want ErrItemNotFound", err)
+		}
+
+		writes := map[string]func() error{
+			"update": func() error {
+				return tenantA.UpdateOrderById(other.ID, &models.Order{CustomerName: "C"}, 0)
+			},
+			"patch": func() error {
+				_, err := tenantA.PatchOrderById(other.ID, 0, func(order *models.Order) error {
+					order.CustomerName = "C"
+					return nil
+				})
+				return err
+			},
+			"create item": func() error {
+				_, err := tenantA.CreateItem(other.ID, &models.Item{ItemCode: "Z", Quantity: 1}, 0)
+				return err
+			},
+			"update item": func() error {
+				_, _, err := tenantA.UpdateItem(other.ID, itemID, 0, func(item *models.Item) error {
+					item.Quantity = 9
+					return nil
+				})
+				return err
+			},
+			"delete item": func() error {
+				_, err := tenantA.DeleteItem(other.ID, itemID, 0)
+				return err
+			},
+			"delete": func() error { return tenantA.DeleteOrderById(other.ID, 0) },
+			"purge":  func() error { return tenantA.PurgeOrderById(other.ID, 0) },
+		}
+		for name, write := range writes {
+			if err := write(); !errors.Is(err, ErrRecordNotFound) {
+				t.Errorf("%s: got error %v, want ErrRecordNotFound", name, err)
+			}
+		}
+		// Moving an item of the other tenant into an own order fails too.
+		_, err = tenantA.PatchOrderById(own.ID, 0, func(order *models.Order) error {
+			order.Items = append(order.Items, models.Item{ID: itemID, ItemCode: "Y", Quantity: 1})
+			return nil
+		})
+		if !errors.Is(err, ErrItemNotInOrder) {
+			t.Errorf("patch in an item of the other tenant: got error %v, want ErrItemNotInOrder", err)
+		}
+
+		got, err := tenantB.GetOrderById(other.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.CustomerName != "B" || got.Version != 1 || got.DeletedAt.Valid {
+			t.Errorf("got order %q at version %d, deleted %v, want it untouched", got.CustomerName, got.Version, got.DeletedAt.Valid)
+		}
+		checkItems(t, got.Items, other.Items)
+		if got.Items[0].Quantity != 1 {
+			t.Errorf("got quantity %d, want the item untouched", got.Items[0].Quantity)
+		}
+	})
+
+	t.Run("CreateOrderOnce", func(t *testing.T) {
+		store := newStore()
+		since := time.Now().Add(-time.Hour)
+		order := newOrder("A", "X")
+		record := IdempotencyKey{Key: "k", RequestHash: "h", StatusCode: 201}
+		respond := func(order *models.Order) ([]byte, error) {
+			return []byte(fmt.Sprint(order.ID)), nil
+		}
+		if err := store.CreateOrderOnce(&order, &record, since, respond); err != nil {
+			t.Fatal(err)
+		}
+		again := newOrder("A", "X")
+		if err := store.CreateOrderOnce(&again, &record, since, respond); !errors.Is(err, ErrIdempotencyKeyUsed) {
+			t.Errorf("reused key: got error %v, want ErrIdempotencyKeyUsed", err)
+		}
+		got, err := store.GetIdempotencyKey("k", since)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got.Response) != fmt.Sprint(order.ID) || got.OrderVersion != order.Version {
+			t.Errorf("got response %q at version %d, want %q at version %d", got.Response, got.OrderVersion, fmt.Sprint(order.ID), order.Version)
+		}
+	})
+}
+
+// newOrder returns an order of customer with one item per item code.
+func newOrder(customer string, itemCodes ...string) models.Order {
+	order := models.Order{CustomerName: customer, Items: []models.Item{}}
+	for _, code := range itemCodes {
+		order.Items = append(order.Items, models.Item{ItemCode: code, Quantity: 1})
+	}
+	return order
+}
+
+// checkItems fails t unless got holds the IDs and item codes of want, in
+// order.
+func checkItems(t *testing.T, got, want []models.Item) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d items, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].ID != want[i].ID || got[i].ItemCode != want[i].ItemCode {
+			t.Errorf("item %d is %d %q, want %d %q", i, got[i].ID, got[i].ItemCode, want[i].ID, want[i].ItemCode)
+		}
+	}
+}
+
+func orderIDs(orders []models.Order) []uint {
+	ids := make([]uint, len(orders))
+	for i, order := range orders {
+		ids[i] = order.ID
+	}
+	return ids
+}
@@ -0,0 +1,281 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"assignment2.id/orderapi/config"
+	"assignment2.id/orderapi/logging"
+	"assignment2.id/orderapi/models"
+)
+
+// ErrTimeout is returned by the store WithTimeouts returns for a call
+// running longer than its timeout. Its queries are cancelled.
+var ErrTimeout error = errors.New("Database tidak menjawab tepat waktu.")
+
+// ErrCanceled is returned by the store WithTimeouts returns for a call cut
+// off because the context given to WithContext was canceled, such as when
+// the client of the request went away.
+var ErrCanceled error = errors.New("Permintaan dibatalkan.")
+
+// operationKind tells which timeout of config.DBTimeouts bounds an operation.
+type operationKind int
+
+const (
+	readOperation operationKind = iota
+	writeOperation
+	purgeOperation
+)
+
+// operations are the OrderStore methods bounded by a timeout.
+var operations = map[string]operationKind{
+	"CreateOrder":                writeOperation,
+	"GetOrderById":               readOperation,
+	"GetOrderByIds":              readOperation,
+	"ListOrders":                 readOperation,
+	"UpdateOrderById":            writeOperation,
+	"PatchOrderById":             writeOperation,
+	"GetItems":                   readOperation,
+	"GetItem":                    readOperation,
+	"CreateItem":                 writeOperation,
+	"UpdateItem":                 writeOperation,
+	"DeleteItem":                 writeOperation,
+	"DeleteOrderById":            writeOperation,
+	"RestoreOrderById":           writeOperation,
+	"PurgeOrderById":             writeOperation,
+	"PurgeDeletedBefore":         purgeOperation,
+	"GetIdempotencyKey":          readOperation,
+	"CreateOrderOnce":            writeOperation,
+	"PurgeIdempotencyKeysBefore": purgeOperation,
+	"CreateAPIKey":               writeOperation,
+	"GetAPIKeyByHash":            readOperation,
+	"ListAPIKeys":                readOperation,
+	"RevokeAPIKey":               writeOperation,
+}
+
+// WithTimeouts returns store bounding each call by its timeout in cfg,
+// under the deadline of the context given to WithContext. It fails when
+// cfg.Operations names an unknown operation.
+func WithTimeouts(store OrderStore, cfg config.DBTimeouts) (OrderStore, error) {
+	kinds := map[operationKind]time.Duration{
+		readOperation:  time.Duration(cfg.Read),
+		writeOperation: time.Duration(cfg.Write),
+		purgeOperation: time.Duration(cfg.Purge),
+	}
+	timeouts := make(map[string]time.Duration, len(operations))
+	for operation, kind := range operations {
+		timeouts[operation] = kinds[kind]
+	}
+	for operation, timeout := range cfg.Operations {
+		if _, ok := operations[operation]; !ok {
+			return nil, fmt.Errorf("db.timeouts.operations: unknown operation %q", operation)
+		}
+		timeouts[operation] = time.Duration(timeout)
+	}
+	return &timeoutStore{OrderStore: store, ctx: context.Background(), timeouts: timeouts}, nil
+}
+
+type timeoutStore struct {
+	OrderStore
+	ctx      context.Context
+	timeouts map[string]time.Duration
+}
+
+func (s *timeoutStore) Scoped(scope Scope) OrderStore {
+	return &timeoutStore{OrderStore: s.OrderStore.Scoped(scope), ctx: s.ctx, timeouts: s.timeouts}
+}
+
+func (s *timeoutStore) WithContext(ctx context.Context) OrderStore {
+	return &timeoutStore{OrderStore: s.OrderStore.WithContext(ctx), ctx: ctx, timeouts: s.timeouts}
+}
+
+// call runs fn with the store bounded by the timeout of operation. It turns
+// the error of a call cut off by a deadline into ErrTimeout, and by the
+// cancellation of its context into ErrCanceled.
+func (s *timeoutStore) call(operation string, fn func(store OrderStore) error) error {
+	ctx, store := s.ctx, s.OrderStore
+	timeout := s.timeouts[operation]
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(s.ctx, timeout)
+		defer cancel()
+		store = store.WithContext(ctx)
+	}
+	err := fn(store)
+	if err == nil {
+		return nil
+	}
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		logging.FromContext(ctx).Warn("store call timed out", "operation", operation, "timeout", timeout, "err", err)
+		return fmt.Errorf("%s: %w", operation, ErrTimeout)
+	case errors.Is(ctx.Err(), context.Canceled):
+		logging.FromContext(ctx).Debug("store call canceled", "operation", operation, "err", err)
+		return fmt.Errorf("%s: %w", operation, ErrCanceled)
+	}
+	return err
+}
+
+func (s *timeoutStore) CreateOrder(order *models.Order) error {
+	return s.call("CreateOrder", func(store OrderStore) error {
+		return store.CreateOrder(order)
+	})
+}
+
+func (s *timeoutStore) GetOrderById(id uint) (order models.Order, err error) {
+	err = s.call("GetOrderById", func(store OrderStore) error {
+		order, err = store.GetOrderById(id)
+		return err
+	})
+	return order, err
+}
+
+func (s *timeoutStore) GetOrderByIds(ids ...uint) (orders []models.Order, missing []uint, err error) {
+	err = s.call("GetOrderByIds", func(store OrderStore) error {
+		orders, missing, err = store.GetOrderByIds(ids...)
+		return err
+	})
+	return orders, missing, err
+}
+
+func (s *timeoutStore) ListOrders(q OrderListQuery) (page OrderPage, err error) {
+	err = s.call("ListOrders", func(store OrderStore) error {
+		page, err = store.ListOrders(q)
+		return err
+	})
+	return page, err
+}
+
+func (s *timeoutStore) UpdateOrderById(id uint, argOrder *models.Order, version uint) error {
+	return s.call("UpdateOrderById", func(store OrderStore) error {
+		return store.UpdateOrderById(id, argOrder, version)
+	})
+}
+
+func (s *timeoutStore) PatchOrderById(id uint, version uint, patch func(order *models.Order) error) (order models.Order, err error) {
+	err = s.call("PatchOrderById", func(store OrderStore) error {
+		order, err = store.PatchOrderById(id, version, patch)
+		return err
+	})
+	return order, err
+}
+
+func (s *timeoutStore) GetItems(orderID uint) (items []models.Item, err error) {
+	err = s.call("GetItems", func(store OrderStore) error {
+		items, err = store.GetItems(orderID)
+		return err
+	})
+	return items, err
+}
+
+func (s *timeoutStore) GetItem(orderID, itemID uint) (item models.Item, err error) {
+	err = s.call("GetItem", func(store OrderStore) error {
+		item, err = store.GetItem(orderID, itemID)
+		return err
+	})
+	return item, err
+}
+
+func (s *timeoutStore) CreateItem(orderID uint, item *models.Item, version uint) (newVersion uint, err error) {
+	err = s.call("CreateItem", func(store OrderStore) error {
+		newVersion, err = store.CreateItem(orderID, item, version)
+		return err
+	})
+	return newVersion, err
+}
+
+func (s *timeoutStore) UpdateItem(orderID, itemID, version uint, update func(item *models.Item) error) (item models.Item, newVersion uint, err error) {
+	err = s.call("UpdateItem", func(store OrderStore) error {
+		item, newVersion, err = store.UpdateItem(orderID, itemID, version, update)
+		return err
+	})
+	return item, newVersion, err
+}
+
+func (s *timeoutStore) DeleteItem(orderID, itemID, version uint) (newVersion uint, err error) {
+	err = s.call("DeleteItem", func(store OrderStore) error {
+		newVersion, err = store.DeleteItem(orderID, itemID, version)
+		return err
+	})
+	return newVersion, err
+}
+
+func (s *timeoutStore) DeleteOrderById(id uint, version uint) error {
+	return s.call("DeleteOrderById", func(store OrderStore) error {
+		return store.DeleteOrderById(id, version)
+	})
+}
+
+func (s *timeoutStore) RestoreOrderById(id uint) error {
+	return s.call("RestoreOrderById", func(store OrderStore) error {
+		return store.RestoreOrderById(id)
+	})
+}
+
+func (s *timeoutStore) PurgeOrderById(id uint, version uint) error {
+	return s.call("PurgeOrderById", func(store OrderStore) error {
+		return store.PurgeOrderById(id, version)
+	})
+}
+
+func (s *timeoutStore) PurgeDeletedBefore(t time.Time) (purged int64, err error) {
+	err = s.call("PurgeDeletedBefore", func(store OrderStore) error {
+		purged, err = store.PurgeDeletedBefore(t)
+		return err
+	})
+	return purged, err
+}
+
+func (s *timeoutStore) GetIdempotencyKey(key string, since time.Time) (record IdempotencyKey, err error) {
+	err = s.call("GetIdempotencyKey", func(store OrderStore) error {
+		record, err = store.GetIdempotencyKey(key, since)
+		return err
+	})
+	return record, err
+}
+
+func (s *timeoutStore) CreateOrderOnce(order *models.Order, record *IdempotencyKey, since time.Time, respond func(order *models.Order) ([]byte, error)) error {
+	return s.call("CreateOrderOnce", func(store OrderStore) error {
+		return store.CreateOrderOnce(order, record, since, respond)
+	})
+}
+
+func (s *timeoutStore) PurgeIdempotencyKeysBefore(t time.Time) (purged int64, err error) {
+	err = s.call("PurgeIdempotencyKeysBefore", func(store OrderStore) error {
+		purged, err = store.PurgeIdempotencyKeysBefore(t)
+		return err
+	})
+	return purged, err
+}
+
+func (s *timeoutStore) CreateAPIKey(key *APIKey) error {
+	return s.call("CreateAPIKey", func(store OrderStore) error {
+		return store.CreateAPIKey(key)
+	})
+}
+
+func (s *timeoutStore) GetAPIKeyByHash(hash string) (key APIKey, err error) {
+	err = s.call("GetAPIKeyByHash", func(store OrderStore) error {
+		key, err = store.GetAPIKeyByHash(hash)
+		return err
+	})
+	return key, err
+}
+
+func (s *timeoutStore) ListAPIKeys() (keys []APIKey, err error) {
+	err = s.call("ListAPIKeys", func(store OrderStore) error {
+		keys, err = store.ListAPIKeys()
+		return err
+	})
+	return keys, err
+}
+
+func (s *timeoutStore) RevokeAPIKey(id uint) (key APIKey, err error) {
+	err = s.call("RevokeAPIKey", func(store OrderStore) error {
+		key, err = store.RevokeAPIKey(id)
+		return err
+	})
+	return key, err
+}
@@ -0,0 +1,69 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"assignment2.id/orderapi/config"
+	"assignment2.id/orderapi/models"
+)
+
+// blockingStore answers GetOrderById with err, or once its context is done
+// with the error of the context when block is set.
+type blockingStore struct {
+	OrderStore
+	ctx   context.Context
+	block bool
+	err   error
+}
+
+func (s *blockingStore) WithContext(ctx context.Context) OrderStore {
+	return &blockingStore{OrderStore: s.OrderStore, ctx: ctx, block: s.block, err: s.err}
+}
+
+func (s *blockingStore) GetOrderById(id uint) (models.Order, error) {
+	if !s.block {
+		return models.Order{}, s.err
+	}
+	<-s.ctx.Done()
+	return models.Order{}, s.ctx.Err()
+}
+
+func TestTimeoutStoreErrors(t *testing.T) {
+	errDriver := errors.New("driver: bad connection")
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		block   bool
+		err     error
+		cancel  bool
+		want    error
+	}{
+		{"timed out", time.Millisecond, true, nil, false, ErrTimeout},
+		{"canceled", time.Hour, true, nil, true, ErrCanceled},
+		{"canceled without a timeout", 0, true, nil, true, ErrCanceled},
+		{"other error", time.Hour, false, errDriver, false, errDriver},
+		{"other error without a timeout", 0, false, errDriver, false, errDriver},
+		{"found", time.Hour, false, nil, false, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := &blockingStore{OrderStore: NewMemoryStore(), ctx: context.Background(), block: tt.block, err: tt.err}
+			store, err := WithTimeouts(inner, config.DBTimeouts{Read: config.Duration(tt.timeout)})
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancel {
+				time.AfterFunc(time.Millisecond, cancel)
+			}
+			_, err = store.WithContext(ctx).GetOrderById(1)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("got error %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+// Package health answers the liveness and readiness probes of the
+// orchestrator running the API.
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrDraining fails the readiness of a server shutting down.
+var ErrDraining error = errors.New("Server sedang berhenti.")
+
+// Statuses of a check and of the whole report.
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// Check reports whether a dependency of the API is usable, returning nil
+// when it is.
+type Check func(ctx context.Context) error
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Checker runs the readiness checks of the API. The zero value is not
+// usable, see New.
+type Checker struct {
+	checks []namedCheck
+	// timeout bounds each check.
+	timeout  time.Duration
+	draining atomic.Bool
+}
+
+// New returns a checker failing the checks that take longer than timeout.
+// It reports whether the server is draining as the "draining" check.
+func New(timeout time.Duration) *Checker {
+	c := &Checker{timeout: timeout}
+	c.Add("draining", func(context.Context) error {
+		if c.draining.Load() {
+			return ErrDraining
+		}
+		return nil
+	})
+	return c
+}
+
+// Add adds the check name to the readiness checks.
+func (c *Checker) Add(name string, check Check) {
+	c.checks = append(c.checks, namedCheck{name: name, check: check})
+}
+
+// Drain makes the server unready, so the orchestrator stops sending it
+// requests while it shuts down.
+func (c *Checker) Drain() {
+	c.draining.Store(true)
+}
+
+// Result is the outcome of one check.
+type Result struct {
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// Report is the body of the probes.
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks,omitempty"`
+}
+
+// Run runs the checks at once and reports each, the report fails when any
+// check does.
+func (c *Checker) Run(ctx context.Context) Report {
+	results := make([]Result, len(c.checks))
+	done := make(chan struct{}, len(c.checks))
+	for i, check := range c.checks {
+		go func(i int, check Check) {
+			results[i] = c.run(ctx, check)
+			done <- struct{}{}
+		}(i, check.check)
+	}
+	for range c.checks {
+		<-done
+	}
+	report := Report{Status: StatusOK, Checks: make(map[string]Result, len(c.checks))}
+	for i, check := range c.checks {
+		report.Checks[check.name] = results[i]
+		if results[i].Status != StatusOK {
+			report.Status = StatusFail
+		}
+	}
+	return report
+}
+
+// run runs check, giving up on it after the timeout even when it ignores its
+// context.
+func (c *Checker) run(ctx context.Context, check Check) Result {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	start := time.Now()
+	errc := make(chan error, 1)
+	go func() { errc <- check(ctx) }()
+	var err error
+	select {
+	case err = <-errc:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	result := Result{Status: StatusOK, Duration: time.Since(start).String()}
+	if err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// Liveness answers 200 as long as the process serves requests.
+func Liveness(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, Report{Status: StatusOK})
+}
+
+// Readiness answers the report of the checks, with 503 when one fails.
+func (c *Checker) Readiness(ctx *gin.Context) {
+	report := c.Run(ctx.Request.Context())
+	status := http.StatusOK
+	if report.Status != StatusOK {
+		status = http.StatusServiceUnavailable
+	}
+	ctx.JSON(status, report)
+}
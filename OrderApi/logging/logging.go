@@ -0,0 +1,64 @@
+// Package logging writes the structured logs of the API: JSON or text lines
+// with a level, carrying the ID of the request they belong to. Request scoped
+// loggers travel in the context of the request, see FromContext.
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+)
+
+// CustomerNameKey is the key of the attribute holding the name of a
+// customer, masked when redacting.
+const CustomerNameKey = "customer_name"
+
+// Redacted replaces the personal data of the logs.
+const Redacted = "[REDACTED]"
+
+var levels = map[string]slog.Level{
+	"debug": slog.LevelDebug,
+	"info":  slog.LevelInfo,
+	"warn":  slog.LevelWarn,
+	"error": slog.LevelError,
+}
+
+// New returns a logger writing to w the records at level or above, in
+// format json or text. redactPII masks the personal data of the records.
+func New(w io.Writer, level, format string, redactPII bool) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: levels[level]}
+	if redactPII {
+		opts.ReplaceAttr = redact
+	}
+	if format == "text" {
+		return slog.New(slog.NewTextHandler(w, opts))
+	}
+	return slog.New(slog.NewJSONHandler(w, opts))
+}
+
+func redact(groups []string, a slog.Attr) slog.Attr {
+	if a.Key == CustomerNameKey {
+		return slog.String(a.Key, Redacted)
+	}
+	return a
+}
+
+type contextKey struct{}
+
+// NewContext returns ctx carrying logger, for FromContext.
+func NewContext(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext returns the logger ctx carries, or the default logger.
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(contextKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// CustomerName is the attribute of the name of a customer.
+func CustomerName(name string) slog.Attr {
+	return slog.String(CustomerNameKey, name)
+}
@@ -0,0 +1,50 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, "info", "json", true)
+	logger.Debug("hidden")
+	logger.Error("request failed", "err", errors.New("pq: connection refused"), CustomerName("Budi"))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("got %d records, want the error only: %s", len(lines), buf.String())
+	}
+	var record map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &record); err != nil {
+		t.Fatal(err)
+	}
+	if record["err"] != "pq: connection refused" {
+		t.Errorf("got err %v, want the message of the error", record["err"])
+	}
+	if record[CustomerNameKey] != Redacted {
+		t.Errorf("got %s %v, want %s", CustomerNameKey, record[CustomerNameKey], Redacted)
+	}
+}
+
+func TestNewKeepsPII(t *testing.T) {
+	var buf bytes.Buffer
+	New(&buf, "debug", "text", false).Debug("order created", CustomerName("Budi"))
+	if !strings.Contains(buf.String(), "customer_name=Budi") {
+		t.Errorf("got %q, want the customer name", buf.String())
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	if FromContext(context.Background()) != slog.Default() {
+		t.Error("got another logger than the default one for an empty context")
+	}
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+	if FromContext(NewContext(context.Background(), logger)) != logger {
+		t.Error("got another logger than the one of the context")
+	}
+}
@@ -0,0 +1,67 @@
+package logging
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader carries the ID of a request, given by the client or a
+// proxy in front of the API, or assigned by Middleware.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds the request IDs taken from clients.
+const maxRequestIDLength = 128
+
+// Middleware gives each request an ID, sent back in the X-Request-ID header,
+// and a logger adding it to every record, stored in the context of the
+// request. Once the request is handled it logs its method, route, status and
+// duration. It goes first, so the requests refused by the other middleware
+// are logged too.
+func Middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		id := ctx.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+		ctx.Header(RequestIDHeader, id)
+		logger := slog.Default().With("request_id", id)
+		ctx.Request = ctx.Request.WithContext(NewContext(ctx.Request.Context(), logger))
+
+		ctx.Next()
+
+		status := ctx.Writer.Status()
+		level := slog.LevelInfo
+		if status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		// The query string is left out, it may hold customer names.
+		logger.Log(ctx.Request.Context(), level, "request",
+			"method", ctx.Request.Method,
+			"route", ctx.FullPath(),
+			"path", ctx.Request.URL.Path,
+			"status", status,
+			"duration", time.Since(start),
+			"bytes", ctx.Writer.Size(),
+			"client_ip", ctx.ClientIP(),
+		)
+	}
+}
+
+// validRequestID reports whether id, taken from a client, is short and
+// printable ASCII without spaces, so it cannot forge log lines.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
@@ -1,25 +1,150 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"assignment2.id/orderapi/config"
 	"assignment2.id/orderapi/database"
-	_ "assignment2.id/orderapi/docs"
+	"assignment2.id/orderapi/health"
+	"assignment2.id/orderapi/logging"
+	"assignment2.id/orderapi/metrics"
 	"assignment2.id/orderapi/routers"
+	"assignment2.id/orderapi/server"
+	"assignment2.id/orderapi/tracing"
+	"github.com/gin-gonic/gin"
 )
 
-// @title           Order API
-// @version         1.0
-// @description     Assignment 2.
-
-// @contact.name   zulkarnaen
-// @contact.email  premiumforspot@gmail.com
-
-// @license.name  Apache 2.0
-// @license.url   http://www.apache.org/licenses/LICENSE-2.0.html
-
-// @host      localhost:8080
-// @BasePath  /
 func main() {
-	database.StartDB()
-	var port = ":8080"
-	routers.StartServer().Run(port)
+	cfg, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	slog.SetDefault(logging.New(os.Stderr, cfg.LogLevel, cfg.LogFormat, cfg.LogRedactPII))
+	if cfg.LogLevel != "debug" {
+		gin.SetMode(gin.ReleaseMode)
+	}
+	store, err := database.Open(cfg.DB, cfg.LogRedactPII)
+	if err != nil {
+		fatal("connecting to the database failed", err)
+	}
+	if len(cfg.Args) > 0 {
+		var run func(database.OrderStore, []string) error
+		switch cfg.Args[0] {
+		case "migrate":
+			run = runMigrate
+		case "apikey":
+			run = runAPIKey
+		default:
+			fmt.Fprintf(os.Stderr, "unknown command %q\n", cfg.Args[0])
+			os.Exit(2)
+		}
+		if err := run(store, cfg.Args[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	if err := checkSchema(store, cfg.DB.AutoMigrate); err != nil {
+		fatal("checking the database schema failed", err)
+	}
+	// The SQL databases are instrumented before the store is wrapped.
+	schemaStore, _ := store.(database.SchemaStore)
+	store, err = database.WithTimeouts(store, cfg.DB.Timeouts)
+	if err != nil {
+		fatal("setting up the database timeouts failed", err)
+	}
+	var m *metrics.Metrics
+	if cfg.Metrics.Enabled {
+		m = metrics.New()
+		if schemaStore != nil {
+			if err := m.Instrument(schemaStore.DB()); err != nil {
+				fatal("instrumenting the database failed", err)
+			}
+		}
+		store = m.Store(store)
+	}
+	if cfg.Tracing.Enabled {
+		shutdown, err := tracing.Start(context.Background(), cfg.Tracing)
+		if err != nil {
+			fatal("setting up tracing failed", err)
+		}
+		defer func() {
+			// Spans still buffered are sent before exiting.
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := shutdown(ctx); err != nil {
+				slog.Error("flushing the spans failed", "err", err)
+			}
+		}()
+		if schemaStore != nil {
+			if err := tracing.Instrument(schemaStore.DB()); err != nil {
+				fatal("instrumenting the database failed", err)
+			}
+		}
+		store = tracing.Store(store)
+	}
+	checker := health.New(time.Duration(cfg.Health.Timeout))
+	checker.Add("database", store.Ping)
+	if schemaStore != nil {
+		migrator, err := database.NewMigrator(schemaStore.DB())
+		if err != nil {
+			fatal("loading the migrations failed", err)
+		}
+		checker.Add("migrations", migrator.Check)
+	}
+	// SIGTERM, sent on deploy, and SIGINT stop the server once the requests
+	// in flight are done, so their transactions are not cut off.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	var purgers sync.WaitGroup
+	if cfg.Purge.Retention > 0 {
+		purgers.Add(1)
+		go func() {
+			defer purgers.Done()
+			database.RunPurger(store, time.Duration(cfg.Purge.Retention), time.Duration(cfg.Purge.Interval), ctx.Done())
+		}()
+	}
+	if cfg.Idempotency.TTL > 0 {
+		purgers.Add(1)
+		go func() {
+			defer purgers.Done()
+			database.RunIdempotencyPurger(store, time.Duration(cfg.Idempotency.TTL), time.Duration(cfg.Idempotency.Interval), ctx.Done())
+		}()
+	}
+	router, err := routers.StartServer(store, cfg, m, checker)
+	if err != nil {
+		fatal("setting up the routes failed", err)
+	}
+	srv, err := server.New(cfg.Server, cfg.ListenAddr, router)
+	if err != nil {
+		fatal("setting up the server failed", err)
+	}
+	srv.OnDrain(checker.Drain)
+	err = srv.Run(ctx)
+	stop()
+	purgers.Wait()
+	if closeErr := store.Close(); closeErr != nil {
+		slog.Error("closing the database failed", "err", closeErr)
+	}
+	if err != nil {
+		fatal("serving failed", err)
+	}
+}
+
+func fatal(msg string, err error) {
+	slog.Error(msg, "err", err)
+	os.Exit(1)
 }
@@ -0,0 +1,82 @@
+package metrics
+
+import (
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"gorm.io/gorm"
+)
+
+// startKey holds, in a GORM statement, when its query started.
+const startKey = "metrics:start"
+
+// Instrument times the queries db runs, by operation, and reports the stats
+// of its connection pool.
+func (m *Metrics) Instrument(db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	if err := m.registry.Register(collectors.NewDBStatsCollector(sqlDB, db.Dialector.Name())); err != nil {
+		return err
+	}
+	return db.Use(gormPlugin{m})
+}
+
+type gormPlugin struct {
+	m *Metrics
+}
+
+func (gormPlugin) Name() string {
+	return "metrics"
+}
+
+// registerer is a GORM callback waiting for its function.
+type registerer interface {
+	Register(name string, fn func(*gorm.DB)) error
+}
+
+func (p gormPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	operations := []struct {
+		name          string
+		before, after registerer
+	}{
+		{"create", cb.Create().Before("gorm:create"), cb.Create().After("gorm:create")},
+		{"query", cb.Query().Before("gorm:query"), cb.Query().After("gorm:query")},
+		{"update", cb.Update().Before("gorm:update"), cb.Update().After("gorm:update")},
+		{"delete", cb.Delete().Before("gorm:delete"), cb.Delete().After("gorm:delete")},
+		{"row", cb.Row().Before("gorm:row"), cb.Row().After("gorm:row")},
+		{"raw", cb.Raw().Before("gorm:raw"), cb.Raw().After("gorm:raw")},
+	}
+	for _, op := range operations {
+		if err := op.before.Register("metrics:before_"+op.name, start); err != nil {
+			return err
+		}
+		if err := op.after.Register("metrics:after_"+op.name, p.observe(op.name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func start(db *gorm.DB) {
+	db.InstanceSet(startKey, time.Now())
+}
+
+// observe returns the callback recording the duration of the queries of
+// operation.
+func (p gormPlugin) observe(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		started, ok := db.InstanceGet(startKey)
+		if !ok {
+			return
+		}
+		table := db.Statement.Table
+		p.m.queryDuration.WithLabelValues(operation, table).Observe(time.Since(started.(time.Time)).Seconds())
+		if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+			p.m.queryErrors.WithLabelValues(operation, table).Inc()
+		}
+	}
+}
@@ -0,0 +1,113 @@
+// Package metrics exposes the metrics of the API to Prometheus: the requests
+// it serves, the queries it runs, its connection pool and what happens to
+// the orders.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "orderapi"
+
+// unmatchedRoute labels the requests matching no route, so the paths
+// clients make up do not each get their own series.
+const unmatchedRoute = "unmatched"
+
+// Metrics are the collectors of the API, in a registry of their own.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	requests        *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	queryDuration   *prometheus.HistogramVec
+	queryErrors     *prometheus.CounterVec
+	orderEvents     *prometheus.CounterVec
+	itemEvents      *prometheus.CounterVec
+	orderItems      prometheus.Histogram
+}
+
+// New returns the metrics of the API, along with those of the Go runtime and
+// of the process.
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests served, by route template, method and status.",
+		}, []string{"route", "method", "status"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Time taken to serve HTTP requests, by route template, method and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method", "status"}),
+		queryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "db_query_duration_seconds",
+			Help:      "Time taken by database queries, by operation and table.",
+			Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"operation", "table"}),
+		queryErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "db_query_errors_total",
+			Help:      "Database queries failed, by operation and table. A query finding no row is no error.",
+		}, []string{"operation", "table"}),
+		orderEvents: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "orders_total",
+			Help:      "Orders created, updated, deleted, restored and purged, by event.",
+		}, []string{"event"}),
+		itemEvents: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "items_total",
+			Help:      "Items created, updated and deleted through the item routes, by event.",
+		}, []string{"event"}),
+		orderItems: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "order_items",
+			Help:      "Items per order, when an order is created or its items are replaced.",
+			Buckets:   []float64{0, 1, 2, 3, 5, 10, 20, 50, 100},
+		}),
+	}
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests, m.requestDuration,
+		m.queryDuration, m.queryErrors,
+		m.orderEvents, m.itemEvents, m.orderItems,
+	)
+	return m
+}
+
+// Handler serves the metrics in the Prometheus text format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// Middleware counts and times the requests. It goes first, so the requests
+// refused by the other middleware are counted too.
+func (m *Metrics) Middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		ctx.Next()
+		route := ctx.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		labels := prometheus.Labels{
+			"route":  route,
+			"method": ctx.Request.Method,
+			"status": strconv.Itoa(ctx.Writer.Status()),
+		}
+		m.requests.With(labels).Inc()
+		m.requestDuration.With(labels).Observe(time.Since(start).Seconds())
+	}
+}
@@ -0,0 +1,131 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"assignment2.id/orderapi/database"
+	"assignment2.id/orderapi/models"
+)
+
+// Events of orders_total and items_total.
+const (
+	eventCreated  = "created"
+	eventUpdated  = "updated"
+	eventDeleted  = "deleted"
+	eventRestored = "restored"
+	eventPurged   = "purged"
+)
+
+// Store returns store counting the orders and items its callers change.
+func (m *Metrics) Store(store database.OrderStore) database.OrderStore {
+	return &countingStore{OrderStore: store, m: m}
+}
+
+type countingStore struct {
+	database.OrderStore
+	m *Metrics
+}
+
+func (s *countingStore) Scoped(scope database.Scope) database.OrderStore {
+	return s.m.Store(s.OrderStore.Scoped(scope))
+}
+
+func (s *countingStore) WithContext(ctx context.Context) database.OrderStore {
+	return s.m.Store(s.OrderStore.WithContext(ctx))
+}
+
+func (s *countingStore) created(order *models.Order) {
+	s.m.orderEvents.WithLabelValues(eventCreated).Inc()
+	s.m.orderItems.Observe(float64(len(order.Items)))
+}
+
+func (s *countingStore) CreateOrder(order *models.Order) error {
+	err := s.OrderStore.CreateOrder(order)
+	if err == nil {
+		s.created(order)
+	}
+	return err
+}
+
+func (s *countingStore) CreateOrderOnce(order *models.Order, record *database.IdempotencyKey, since time.Time, respond func(order *models.Order) ([]byte, error)) error {
+	err := s.OrderStore.CreateOrderOnce(order, record, since, respond)
+	if err == nil {
+		s.created(order)
+	}
+	return err
+}
+
+func (s *countingStore) UpdateOrderById(id uint, argOrder *models.Order, version uint) error {
+	err := s.OrderStore.UpdateOrderById(id, argOrder, version)
+	if err == nil {
+		s.m.orderEvents.WithLabelValues(eventUpdated).Inc()
+		if argOrder.Items != nil {
+			s.m.orderItems.Observe(float64(len(argOrder.Items)))
+		}
+	}
+	return err
+}
+
+func (s *countingStore) PatchOrderById(id uint, version uint, patch func(order *models.Order) error) (models.Order, error) {
+	order, err := s.OrderStore.PatchOrderById(id, version, patch)
+	if err == nil {
+		s.m.orderEvents.WithLabelValues(eventUpdated).Inc()
+		s.m.orderItems.Observe(float64(len(order.Items)))
+	}
+	return order, err
+}
+
+func (s *countingStore) DeleteOrderById(id uint, version uint) error {
+	err := s.OrderStore.DeleteOrderById(id, version)
+	if err == nil {
+		s.m.orderEvents.WithLabelValues(eventDeleted).Inc()
+	}
+	return err
+}
+
+func (s *countingStore) RestoreOrderById(id uint) error {
+	err := s.OrderStore.RestoreOrderById(id)
+	if err == nil {
+		s.m.orderEvents.WithLabelValues(eventRestored).Inc()
+	}
+	return err
+}
+
+func (s *countingStore) PurgeOrderById(id uint, version uint) error {
+	err := s.OrderStore.PurgeOrderById(id, version)
+	if err == nil {
+		s.m.orderEvents.WithLabelValues(eventPurged).Inc()
+	}
+	return err
+}
+
+func (s *countingStore) PurgeDeletedBefore(t time.Time) (int64, error) {
+	purged, err := s.OrderStore.PurgeDeletedBefore(t)
+	s.m.orderEvents.WithLabelValues(eventPurged).Add(float64(purged))
+	return purged, err
+}
+
+func (s *countingStore) CreateItem(orderID uint, item *models.Item, version uint) (uint, error) {
+	newVersion, err := s.OrderStore.CreateItem(orderID, item, version)
+	if err == nil {
+		s.m.itemEvents.WithLabelValues(eventCreated).Inc()
+	}
+	return newVersion, err
+}
+
+func (s *countingStore) UpdateItem(orderID, itemID, version uint, update func(item *models.Item) error) (models.Item, uint, error) {
+	item, newVersion, err := s.OrderStore.UpdateItem(orderID, itemID, version, update)
+	if err == nil {
+		s.m.itemEvents.WithLabelValues(eventUpdated).Inc()
+	}
+	return item, newVersion, err
+}
+
+func (s *countingStore) DeleteItem(orderID, itemID, version uint) (uint, error) {
+	newVersion, err := s.OrderStore.DeleteItem(orderID, itemID, version)
+	if err == nil {
+		s.m.itemEvents.WithLabelValues(eventDeleted).Inc()
+	}
+	return newVersion, err
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"assignment2.id/orderapi/database"
+)
+
+const migrateUsage = "usage: orderapi [flags] migrate up|down|status|to <version>"
+
+func migratorFor(store database.OrderStore) (*database.Migrator, error) {
+	schemaStore, ok := store.(database.SchemaStore)
+	if !ok {
+		return nil, nil
+	}
+	return database.NewMigrator(schemaStore.DB())
+}
+
+// checkSchema refuses to serve on a schema behind the embedded migrations,
+// unless autoMigrate asks to bring it up to date first.
+func checkSchema(store database.OrderStore, autoMigrate bool) error {
+	migrator, err := migratorFor(store)
+	if err != nil || migrator == nil {
+		return err
+	}
+	if autoMigrate {
+		ran, err := migrator.Up()
+		for _, m := range ran {
+			slog.Info("migration applied", "version", m.Version, "name", m.Name)
+		}
+		return err
+	}
+	return migrator.CheckCurrent()
+}
+
+func runMigrate(store database.OrderStore, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+	migrator, err := migratorFor(store)
+	if err != nil {
+		return err
+	}
+	if migrator == nil {
+		return errors.New("the configured db driver has no schema to migrate")
+	}
+	var ran []database.Migration
+	switch args[0] {
+	case "up":
+		ran, err = migrator.Up()
+	case "down":
+		ran, err = migrator.Down()
+	case "to":
+		if len(args) != 2 {
+			return errors.New(migrateUsage)
+		}
+		version, parseErr := strconv.ParseUint(args[1], 10, 0)
+		if parseErr != nil {
+			return fmt.Errorf("version %q: %w", args[1], parseErr)
+		}
+		ran, err = migrator.To(uint(version))
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, s := range statuses {
+			appliedAt := "pending"
+			if s.AppliedAt != nil {
+				appliedAt = s.AppliedAt.Format("2006-01-02 15:04:05 MST")
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", s.Version, s.Name, appliedAt)
+		}
+		return w.Flush()
+	default:
+		return errors.New(migrateUsage)
+	}
+	for _, m := range ran {
+		fmt.Printf("%04d_%s\n", m.Version, m.Name)
+	}
+	if err == nil && len(ran) == 0 {
+		fmt.Println("nothing to migrate")
+	}
+	return err
+}
@@ -7,39 +7,45 @@ import (
 	"gorm.io/gorm"
 )
 
-type ItemBody struct {
-	ItemCode    string `example:"SOMECODE"`
-	Description string `example:"Some description."`
-	Quantity    uint   `example:"1"`
-}
-type OrderBody struct {
-	CustomerName string `example:"Test"`
-	Items        []ItemBody
-	OrderedAt    time.Time `example:"2019-11-09T21:21:46+00:00"`
-}
 type Item struct {
 	ID          uint   `gorm:"primaryKey" example:"1"`
-	ItemCode    string `gorm:"not null;type:varchar(8192)" example:"Contoh"`
-	Description string `gorm:"type:varchar(8192)" example:"Some description."`
-	Quantity    uint   `gorm:"not null" example:"1"`
-	OrderID     uint   `example:"1"`
+	ItemCode    string `gorm:"not null;type:varchar(8192);index" validate:"required,max=64,itemcode" example:"Contoh"`
+	Description string `gorm:"type:varchar(8192)" validate:"max=8192" example:"Some description."`
+	Quantity    uint   `gorm:"not null" validate:"gt=0" example:"1"`
+	OrderID     uint   `gorm:"not null;index" example:"1"`
+	// TenantID is the tenant of the order of the item.
+	TenantID  string         `gorm:"size:64;not null;default:'';index" example:"acme"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-" swaggerignore:"true"`
 }
 type Order struct {
-	ID           uint   `gorm:"primaryKey" example:"1"`
-	CustomerName string `gorm:"type:varchar(8192)" example:"Contoh"`
-	Items        []Item
-	OrderedAt    time.Time `gorm:"not null" example:"2019-11-09T21:21:46+00:00"`
+	ID           uint      `gorm:"primaryKey" example:"1"`
+	CustomerName string    `gorm:"type:varchar(8192)" validate:"required,max=8192" example:"Contoh"`
+	Items        []Item    `gorm:"constraint:OnDelete:CASCADE" validate:"max=100,dive"`
+	OrderedAt    time.Time `gorm:"not null;index" validate:"notfarahead" example:"2019-11-09T21:21:46+00:00"`
+	Version      uint      `gorm:"not null;default:1" example:"1"`
+	// Owner is the subject of the principal the order belongs to.
+	Owner string `gorm:"size:255;not null;default:'';index" example:"alice"`
+	// TenantID is the business unit the order belongs to.
+	TenantID  string         `gorm:"size:64;not null;default:'';index" example:"acme"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-" swaggerignore:"true"`
 }
 
 var ErrItemCodeEmpty error = errors.New("ItemCode kosong.")
 var ErrCustomerNameEmpty error = errors.New("CustomerName kosong.")
 
-func (i *Item) BeforeCreate(tx *gorm.DB) (err error) {
+func (i *Item) BeforeSave(tx *gorm.DB) (err error) {
 	if i.ItemCode == "" {
 		err = ErrItemCodeEmpty
 	}
 	return
 }
+
+// BeforeSave stores OrderedAt in UTC. sqlite compares the times as text, so
+// an offset would break the sort and the filters on it.
+func (o *Order) BeforeSave(tx *gorm.DB) (err error) {
+	o.OrderedAt = o.OrderedAt.UTC()
+	return
+}
 func (o *Order) BeforeCreate(tx *gorm.DB) (err error) {
 	if o.CustomerName == "" {
 		err = ErrCustomerNameEmpty
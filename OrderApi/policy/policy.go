@@ -0,0 +1,72 @@
+// Package policy decides what a principal may do. Each action is granted
+// to roles, either on every order or only on the orders the principal owns:
+//
+//	user     reads, creates and modifies the orders it owns
+//	support  reads every order
+//	admin    does everything, including deleting, restoring and purging
+//
+// The handlers consult Authorize before acting and run the store queries in
+// the Scope it returns, so orders out of reach look missing.
+package policy
+
+import (
+	"net/http"
+
+	"assignment2.id/orderapi/apierror"
+	"assignment2.id/orderapi/auth"
+	"assignment2.id/orderapi/database"
+)
+
+// Action is something a principal does to orders.
+type Action string
+
+const (
+	ReadOrder    Action = "order:read"
+	CreateOrder  Action = "order:create"
+	UpdateOrder  Action = "order:update"
+	DeleteOrder  Action = "order:delete"
+	RestoreOrder Action = "order:restore"
+	PurgeOrder   Action = "order:purge"
+	ManageKeys   Action = "apikey:manage"
+)
+
+// Reach is how far a grant of an action extends.
+type Reach int
+
+const (
+	// Own grants the action on the orders owned by the principal.
+	Own Reach = iota + 1
+	// All grants the action on every order.
+	All
+)
+
+// grants lists, per action, the roles granted it and how far.
+var grants = map[Action]map[string]Reach{
+	ReadOrder:    {auth.RoleUser: Own, auth.RoleSupport: All, auth.RoleAdmin: All},
+	CreateOrder:  {auth.RoleUser: Own, auth.RoleAdmin: All},
+	UpdateOrder:  {auth.RoleUser: Own, auth.RoleAdmin: All},
+	DeleteOrder:  {auth.RoleAdmin: All},
+	RestoreOrder: {auth.RoleAdmin: All},
+	PurgeOrder:   {auth.RoleAdmin: All},
+	ManageKeys:   {auth.RoleAdmin: All},
+}
+
+// Authorize returns the scope in which principal may do action, or a 403
+// error when none of its roles is granted the action.
+func Authorize(principal *auth.Principal, action Action) (database.Scope, error) {
+	var reach Reach
+	if principal != nil {
+		for _, role := range principal.Roles {
+			if r := grants[action][role]; r > reach {
+				reach = r
+			}
+		}
+	}
+	switch reach {
+	case All:
+		return database.Scope{}, nil
+	case Own:
+		return database.Scope{Owner: principal.Subject}, nil
+	}
+	return database.Scope{}, apierror.New(http.StatusForbidden, apierror.CodeForbidden, action)
+}
@@ -0,0 +1,87 @@
+package policy
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"assignment2.id/orderapi/apierror"
+	"assignment2.id/orderapi/auth"
+	"assignment2.id/orderapi/database"
+)
+
+var actions = []Action{ReadOrder, CreateOrder, UpdateOrder, DeleteOrder, RestoreOrder, PurgeOrder, ManageKeys}
+
+func TestAuthorize(t *testing.T) {
+	// want is the reach of each role per action, 0 for forbidden.
+	want := map[string]map[Action]Reach{
+		auth.RoleUser: {
+			ReadOrder:   Own,
+			CreateOrder: Own,
+			UpdateOrder: Own,
+		},
+		auth.RoleSupport: {
+			ReadOrder: All,
+		},
+		auth.RoleAdmin: {
+			ReadOrder:    All,
+			CreateOrder:  All,
+			UpdateOrder:  All,
+			DeleteOrder:  All,
+			RestoreOrder: All,
+			PurgeOrder:   All,
+			ManageKeys:   All,
+		},
+		"":        {},
+		"unknown": {},
+	}
+	for role, reaches := range want {
+		for _, action := range actions {
+			t.Run(role+"/"+string(action), func(t *testing.T) {
+				principal := &auth.Principal{Subject: "alice", Roles: []string{role}}
+				if role == "" {
+					principal.Roles = nil
+				}
+				checkScope(t, principal, action, reaches[action])
+			})
+		}
+	}
+}
+
+func TestAuthorizeCombinesRoles(t *testing.T) {
+	principal := &auth.Principal{Subject: "alice", Roles: []string{auth.RoleUser, auth.RoleSupport}}
+	for _, action := range actions {
+		want := map[Action]Reach{ReadOrder: All, CreateOrder: Own, UpdateOrder: Own}[action]
+		t.Run(string(action), func(t *testing.T) {
+			checkScope(t, principal, action, want)
+		})
+	}
+}
+
+func TestAuthorizeWithoutPrincipal(t *testing.T) {
+	for _, action := range actions {
+		checkScope(t, nil, action, 0)
+	}
+}
+
+// checkScope checks that Authorize gives principal the scope of reach for
+// action, or 403 for reach 0.
+func checkScope(t *testing.T, principal *auth.Principal, action Action, reach Reach) {
+	t.Helper()
+	scope, err := Authorize(principal, action)
+	switch reach {
+	case All:
+		if err != nil || scope != (database.Scope{}) {
+			t.Errorf("%s: got scope %+v, error %v, want every order", action, scope, err)
+		}
+	case Own:
+		if err != nil || scope != (database.Scope{Owner: principal.Subject}) {
+			t.Errorf("%s: got scope %+v, error %v, want the orders of %s", action, scope, err, principal.Subject)
+		}
+	default:
+		var apiErr *apierror.Error
+		if !errors.As(err, &apiErr) || apiErr.Status != http.StatusForbidden || apiErr.Code != apierror.CodeForbidden {
+			t.Errorf("%s: got scope %+v, error %v, want 403", action, scope, err)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"assignment2.id/orderapi/apierror"
+	"assignment2.id/orderapi/auth"
+	"assignment2.id/orderapi/logging"
+	"github.com/gin-gonic/gin"
+)
+
+// remainingKey holds, in the gin context, the Remaining of the most
+// restrictive limit checked for the request.
+const remainingKey = "ratelimit.remaining"
+
+// Check takes a token for key from the bucket of the limit called name. The
+// most restrictive of the limits checked for a request is reported in the
+// RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset and RateLimit-Policy
+// headers. An empty bucket aborts the request with 429 and a Retry-After
+// header and reports false. A failing store lets the request through, so an
+// outage of a shared store does not take the API down with it.
+func Check(ctx *gin.Context, store Store, name string, limit Limit, key string) bool {
+	if limit.Unlimited() {
+		return true
+	}
+	result, err := store.Take(name+"\x00"+key, limit)
+	if err != nil {
+		logging.FromContext(ctx.Request.Context()).Error("rate limit store failed", "err", err, "limit", name)
+		return true
+	}
+	if previous, ok := ctx.Get(remainingKey); !ok || !result.Allowed || result.Remaining <= previous.(int) {
+		ctx.Set(remainingKey, result.Remaining)
+		ctx.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+		ctx.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		ctx.Header("RateLimit-Reset", strconv.Itoa(seconds(result.Reset)))
+		ctx.Header("RateLimit-Policy", fmt.Sprintf("%d;w=%d", limit.Burst, seconds(limit.wait(float64(limit.Burst)))))
+	}
+	if !result.Allowed {
+		retryAfter := seconds(result.RetryAfter)
+		if retryAfter < 1 {
+			retryAfter = 1
+		}
+		ctx.Header("Retry-After", strconv.Itoa(retryAfter))
+		apierror.Abort(ctx, apierror.New(http.StatusTooManyRequests, apierror.CodeRateLimited, retryAfter))
+		return false
+	}
+	return true
+}
+
+// seconds rounds d up to whole seconds.
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// ByIP limits the requests of each client IP, authenticated or not.
+func ByIP(store Store, limit Limit) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !Check(ctx, store, "ip", limit, ctx.ClientIP()) {
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// ClientLimits are the limits of each client, by the kind of request.
+type ClientLimits struct {
+	// Read limits the GET, HEAD and OPTIONS requests, Write the others.
+	Read  Limit
+	Write Limit
+	// Routes replace Read or Write on single routes, keyed by method and
+	// path pattern such as "POST /orders". Each route has its own buckets.
+	Routes map[string]Limit
+	// TrimPrefix is cut from the path of routes before looking them up in
+	// Routes, so the routes of every API version share their limits.
+	TrimPrefix string
+}
+
+// For returns the name and the limit of the requests to the route with
+// method and path pattern.
+func (l ClientLimits) For(method, path string) (string, Limit) {
+	route := method + " " + strings.TrimPrefix(path, l.TrimPrefix)
+	if limit, ok := l.Routes[route]; ok {
+		return route, limit
+	}
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return "read", l.Read
+	}
+	return "write", l.Write
+}
+
+// ByClient limits the requests of each client, told apart by its API key or
+// JWT subject, or by IP when authentication is disabled. It goes after the
+// authentication middleware.
+func ByClient(store Store, limits ClientLimits) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		name, limit := limits.For(ctx.Request.Method, ctx.FullPath())
+		if !Check(ctx, store, name, limit, clientKey(ctx)) {
+			return
+		}
+		ctx.Next()
+	}
+}
+
+func clientKey(ctx *gin.Context) string {
+	principal := auth.PrincipalFrom(ctx)
+	if principal == nil || principal.Method == auth.MethodNone {
+		return "ip:" + ctx.ClientIP()
+	}
+	return principal.Method + ":" + principal.Tenant + ":" + principal.Subject
+}
@@ -0,0 +1,113 @@
+// Package ratelimit limits how often clients call the API with token
+// buckets: each key, such as a client IP or an API key, has a bucket
+// refilled at a steady rate, and a request takes a token or is refused with
+// 429. The buckets live in a Store, in process memory by default.
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Limit is the rate a bucket refills at, in requests per second, and the
+// burst of requests it holds when full. A zero Rate is unlimited.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Unlimited reports whether l lets every request through.
+func (l Limit) Unlimited() bool {
+	return l.Rate <= 0
+}
+
+// wait returns how long l takes to refill tokens.
+func (l Limit) wait(tokens float64) time.Duration {
+	return time.Duration(tokens / l.Rate * float64(time.Second))
+}
+
+// Result is the state of a bucket after a request tried to take a token.
+type Result struct {
+	Allowed bool
+	// Limit is the burst of the bucket and Remaining the requests it still
+	// lets through at once.
+	Limit     int
+	Remaining int
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is how long until a refused request would be allowed.
+	RetryAfter time.Duration
+}
+
+// Store keeps the token buckets. Implementations backed by a shared
+// database let several servers enforce one limit.
+type Store interface {
+	// Take takes a token from the bucket of key, filled and refilled as
+	// limit says.
+	Take(key string, limit Limit) (Result, error)
+}
+
+// sweepInterval is how often a MemoryStore drops the buckets that are full
+// again.
+const sweepInterval = time.Minute
+
+// MemoryStore is a Store keeping the buckets in process memory, so every
+// server has its own. It is safe for concurrent use.
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	swept   time.Time
+	// now is the clock the buckets refill by, replaced in tests.
+	now func() time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	// full is when the bucket is full again, and equal to a new bucket.
+	full time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*bucket), now: time.Now}
+}
+
+func (s *MemoryStore) Take(key string, limit Limit) (Result, error) {
+	burst := float64(limit.Burst)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	s.sweep(now)
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: burst, last: now}
+		s.buckets[key] = b
+	}
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.last).Seconds()*limit.Rate)
+	b.last = now
+	result := Result{Limit: limit.Burst}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = limit.wait(1 - b.tokens)
+	}
+	result.Remaining = int(b.tokens)
+	result.Reset = limit.wait(burst - b.tokens)
+	b.full = now.Add(result.Reset)
+	return result, nil
+}
+
+// sweep drops the buckets that are full again. s.mu must be held.
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.swept) < sweepInterval {
+		return
+	}
+	for key, b := range s.buckets {
+		if !b.full.After(now) {
+			delete(s.buckets, key)
+		}
+	}
+	s.swept = now
+}
@@ -0,0 +1,187 @@
+package ratelimit
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"assignment2.id/orderapi/apierror"
+	"github.com/gin-gonic/gin"
+)
+
+// clock is a time that only moves when advanced.
+type clock struct {
+	t time.Time
+}
+
+func (c *clock) now() time.Time {
+	return c.t
+}
+
+func (c *clock) advance(d time.Duration) {
+	c.t = c.t.Add(d)
+}
+
+// newTestStore returns a MemoryStore running on a clock of its own.
+func newTestStore() (*MemoryStore, *clock) {
+	c := &clock{t: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)}
+	store := NewMemoryStore()
+	store.now = c.now
+	return store, c
+}
+
+func TestMemoryStoreTake(t *testing.T) {
+	store, clock := newTestStore()
+	limit := Limit{Rate: 2, Burst: 3}
+	steps := []struct {
+		name       string
+		advance    time.Duration
+		allowed    bool
+		remaining  int
+		reset      time.Duration
+		retryAfter time.Duration
+	}{
+		{"full bucket", 0, true, 2, 500 * time.Millisecond, 0},
+		{"second of the burst", 0, true, 1, time.Second, 0},
+		{"last of the burst", 0, true, 0, 1500 * time.Millisecond, 0},
+		{"empty bucket", 0, false, 0, 1500 * time.Millisecond, 500 * time.Millisecond},
+		{"half a token refilled", 250 * time.Millisecond, false, 0, 1250 * time.Millisecond, 250 * time.Millisecond},
+		{"a token refilled", 250 * time.Millisecond, true, 0, 1500 * time.Millisecond, 0},
+		{"refill stops at the burst", time.Hour, true, 2, 500 * time.Millisecond, 0},
+	}
+	for _, step := range steps {
+		clock.advance(step.advance)
+		got, err := store.Take("k", limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := Result{Allowed: step.allowed, Limit: 3, Remaining: step.remaining, Reset: step.reset, RetryAfter: step.retryAfter}
+		if got != want {
+			t.Errorf("%s: got %+v, want %+v", step.name, got, want)
+		}
+	}
+}
+
+func TestMemoryStoreKeysAreSeparate(t *testing.T) {
+	store, _ := newTestStore()
+	limit := Limit{Rate: 1, Burst: 1}
+	for _, key := range []string{"a", "b"} {
+		if result, _ := store.Take(key, limit); !result.Allowed {
+			t.Errorf("first request of %s refused", key)
+		}
+	}
+	if result, _ := store.Take("a", limit); result.Allowed {
+		t.Error("second request of a allowed")
+	}
+}
+
+func TestMemoryStoreSweepsFullBuckets(t *testing.T) {
+	store, clock := newTestStore()
+	limit := Limit{Rate: 1, Burst: 10}
+	store.Take("idle", limit)
+	clock.advance(sweepInterval)
+	store.Take("busy", limit)
+	if _, ok := store.buckets["idle"]; ok {
+		t.Error("the full bucket of idle was kept")
+	}
+	if _, ok := store.buckets["busy"]; !ok {
+		t.Error("the bucket of busy was dropped")
+	}
+}
+
+type failingStore struct{}
+
+func (failingStore) Take(string, Limit) (Result, error) {
+	return Result{}, errors.New("store down")
+}
+
+// serve sends GET / through handler and returns the response.
+func serve(handlers ...gin.HandlerFunc) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(apierror.Middleware(func(error) *apierror.Error { return nil }))
+	router.GET("/", append(handlers, func(ctx *gin.Context) { ctx.Status(http.StatusOK) })...)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	return w
+}
+
+func TestCheckHeaders(t *testing.T) {
+	store, clock := newTestStore()
+	limit := ByIP(store, Limit{Rate: 0.5, Burst: 2})
+	steps := []struct {
+		advance    time.Duration
+		status     int
+		remaining  string
+		reset      string
+		retryAfter string
+	}{
+		{0, http.StatusOK, "1", "2", ""},
+		{0, http.StatusOK, "0", "4", ""},
+		{0, http.StatusTooManyRequests, "0", "4", "2"},
+		{time.Second, http.StatusTooManyRequests, "0", "3", "1"},
+		{time.Second, http.StatusOK, "0", "4", ""},
+	}
+	for i, step := range steps {
+		clock.advance(step.advance)
+		w := serve(limit)
+		if w.Code != step.status {
+			t.Fatalf("request %d: got status %d, want %d", i+1, w.Code, step.status)
+		}
+		header := w.Header()
+		got := []string{header.Get("RateLimit-Limit"), header.Get("RateLimit-Remaining"), header.Get("RateLimit-Reset"), header.Get("RateLimit-Policy"), header.Get("Retry-After")}
+		want := []string{"2", step.remaining, step.reset, "2;w=4", step.retryAfter}
+		for j, name := range []string{"RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy", "Retry-After"} {
+			if got[j] != want[j] {
+				t.Errorf("request %d: got %s %q, want %q", i+1, name, got[j], want[j])
+			}
+		}
+		if w.Code == http.StatusTooManyRequests {
+			var problem apierror.Problem
+			if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+				t.Fatal(err)
+			}
+			if problem.Code != apierror.CodeRateLimited {
+				t.Errorf("request %d: got code %q, want %q", i+1, problem.Code, apierror.CodeRateLimited)
+			}
+		}
+	}
+}
+
+func TestCheckRetryAfterIsAtLeastASecond(t *testing.T) {
+	store, _ := newTestStore()
+	limit := ByIP(store, Limit{Rate: 10, Burst: 1})
+	serve(limit)
+	w := serve(limit)
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "1" {
+		t.Errorf("got status %d, Retry-After %q, want 429 after 1", w.Code, w.Header().Get("Retry-After"))
+	}
+}
+
+func TestCheckReportsTheMostRestrictiveLimit(t *testing.T) {
+	store, _ := newTestStore()
+	loose := ByIP(store, Limit{Rate: 1, Burst: 10})
+	strict := ByClient(store, ClientLimits{Read: Limit{Rate: 1, Burst: 3}})
+	w := serve(loose, strict)
+	if got := w.Header().Get("RateLimit-Limit"); got != "3" {
+		t.Errorf("got RateLimit-Limit %q, want 3 of the stricter limit", got)
+	}
+	if got := w.Header().Get("RateLimit-Remaining"); got != "2" {
+		t.Errorf("got RateLimit-Remaining %q, want 2", got)
+	}
+}
+
+func TestCheckLetsRequestsThrough(t *testing.T) {
+	for name, handler := range map[string]gin.HandlerFunc{
+		"unlimited":     ByIP(NewMemoryStore(), Limit{}),
+		"failing store": ByIP(failingStore{}, Limit{Rate: 1, Burst: 1}),
+	} {
+		w := serve(handler)
+		if w.Code != http.StatusOK || w.Header().Get("RateLimit-Limit") != "" {
+			t.Errorf("%s: got status %d, RateLimit-Limit %q, want 200 without headers", name, w.Code, w.Header().Get("RateLimit-Limit"))
+		}
+	}
+}
@@ -1,18 +1,120 @@
 package routers
 
 import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"assignment2.id/orderapi/apierror"
+	"assignment2.id/orderapi/auth"
+	"assignment2.id/orderapi/config"
 	"assignment2.id/orderapi/controllers"
+	v1 "assignment2.id/orderapi/controllers/v1"
+	"assignment2.id/orderapi/database"
+	_ "assignment2.id/orderapi/docs/v1"
+	"assignment2.id/orderapi/health"
+	"assignment2.id/orderapi/logging"
+	"assignment2.id/orderapi/metrics"
+	"assignment2.id/orderapi/ratelimit"
+	"assignment2.id/orderapi/tenancy"
+	"assignment2.id/orderapi/tracing"
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
-func StartServer() *gin.Engine {
-	router := gin.Default()
-	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
-	router.GET("/orders/:orderID", controllers.GetOrder)
-	router.PUT("/orders/:orderID", controllers.UpdateOrder)
-	router.POST("/orders", controllers.CreateOrder)
-	router.DELETE("/orders/:orderID", controllers.DeleteOrder)
-	return router
+// The unversioned paths are aliases of /v1, deprecated since
+// unversionedDeprecated and removed at unversionedSunset.
+var (
+	unversionedDeprecated = time.Date(2026, time.October, 1, 0, 0, 0, 0, time.UTC)
+	unversionedSunset     = time.Date(2027, time.April, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// StartServer returns the router of the API, reporting its requests to m
+// unless it is nil, and its readiness with checker.
+func StartServer(store database.OrderStore, cfg *config.Config, m *metrics.Metrics, checker *health.Checker) (*gin.Engine, error) {
+	authenticate := auth.Anonymous()
+	if cfg.Auth.Enabled {
+		authenticator, err := auth.New(cfg.Auth, store)
+		if err != nil {
+			return nil, err
+		}
+		authenticate = authenticator.Middleware()
+	}
+
+	router := gin.New()
+	router.Use(logging.Middleware())
+	if cfg.Tracing.Enabled {
+		router.Use(tracing.Middleware())
+	}
+	if m != nil {
+		router.Use(m.Middleware())
+	}
+	if err := router.SetTrustedProxies(cfg.RateLimit.TrustedProxies); err != nil {
+		return nil, err
+	}
+	router.Use(controllers.ErrorHandler(), controllers.Recovery())
+	router.NoRoute(apierror.NotFound)
+	// The probes and the metrics are registered before the rate limits,
+	// which would turn the orchestrator and scrapers away.
+	router.GET("/healthz", health.Liveness)
+	router.GET("/readyz", checker.Readiness)
+	if m != nil {
+		router.GET(cfg.Metrics.Path, gin.WrapH(m.Handler()))
+	}
+
+	// The limits of clients are checked once they are authenticated, the
+	// limit of their IP before.
+	limits := ratelimit.NewMemoryStore()
+	limitClient := gin.HandlerFunc(func(ctx *gin.Context) { ctx.Next() })
+	if cfg.RateLimit.Enabled {
+		router.Use(ratelimit.ByIP(limits, rateLimit(cfg.RateLimit.IP)))
+		clientLimits := ratelimit.ClientLimits{
+			Read:       rateLimit(cfg.RateLimit.Read),
+			Write:      rateLimit(cfg.RateLimit.Write),
+			Routes:     make(map[string]ratelimit.Limit, len(cfg.RateLimit.Routes)),
+			TrimPrefix: "/v1",
+		}
+		for route, limit := range cfg.RateLimit.Routes {
+			clientLimits.Routes[route] = rateLimit(limit)
+		}
+		limitClient = ratelimit.ByClient(limits, clientLimits)
+	}
+
+	// The order routes of a tenant only reach its orders.
+	tenant := gin.HandlerFunc(func(ctx *gin.Context) { ctx.Next() })
+	if cfg.Tenancy.Enabled {
+		tenant = tenancy.New(cfg.Tenancy, limits).Middleware()
+	}
+
+	orders := v1.NewOrderController(store, time.Duration(cfg.Idempotency.TTL), cfg.Tenancy.MaxOrders)
+	apiKeys := v1.NewAPIKeyController(store)
+	v1Group := router.Group("/v1")
+	v1Group.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler, ginSwagger.InstanceName("v1")))
+	v1API := v1Group.Group("", authenticate, limitClient)
+	orders.Register(v1API.Group("", tenant))
+	apiKeys.Register(v1API)
+
+	unversioned := router.Group("/", deprecated("/v1", unversionedDeprecated, unversionedSunset))
+	unversioned.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler, ginSwagger.InstanceName("v1")))
+	orders.Register(unversioned.Group("", authenticate, limitClient, tenant))
+	return router, nil
+}
+
+func rateLimit(limit config.RateLimit) ratelimit.Limit {
+	return ratelimit.Limit{Rate: limit.Rate, Burst: limit.Burst}
+}
+
+// deprecated marks the responses of a route group as deprecated in favour of
+// the same path under successor, with the Deprecation (RFC 9745) and Sunset
+// (RFC 8594) headers.
+func deprecated(successor string, since, sunset time.Time) gin.HandlerFunc {
+	deprecation := "@" + strconv.FormatInt(since.Unix(), 10)
+	sunsetDate := sunset.Format(http.TimeFormat)
+	return func(ctx *gin.Context) {
+		ctx.Header("Deprecation", deprecation)
+		ctx.Header("Sunset", sunsetDate)
+		ctx.Header("Link", "<"+successor+ctx.Request.URL.RequestURI()+`>; rel="successor-version"`)
+		ctx.Next()
+	}
 }
@@ -0,0 +1,121 @@
+// Package server runs the HTTP server of the API with the timeouts and
+// limits of its config, optionally over TLS with a certificate reloaded from
+// disk, and shuts it down gracefully.
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"assignment2.id/orderapi/config"
+)
+
+// Server is an http.Server draining its requests on shutdown.
+type Server struct {
+	http            *http.Server
+	shutdownTimeout time.Duration
+	drainDelay      time.Duration
+	// onDrain are called once the server starts shutting down.
+	onDrain []func()
+	// certs serves the TLS certificate, nil speaks plain HTTP.
+	certs *certReloader
+}
+
+// New returns a server of handler on addr configured by cfg. It fails when
+// the TLS certificate of cfg cannot be loaded.
+func New(cfg config.ServerConfig, addr string, handler http.Handler) (*Server, error) {
+	s := &Server{
+		http: &http.Server{
+			Addr:              addr,
+			Handler:           handler,
+			ReadHeaderTimeout: time.Duration(cfg.ReadHeaderTimeout),
+			ReadTimeout:       time.Duration(cfg.ReadTimeout),
+			WriteTimeout:      time.Duration(cfg.WriteTimeout),
+			IdleTimeout:       time.Duration(cfg.IdleTimeout),
+			MaxHeaderBytes:    cfg.MaxHeaderBytes,
+		},
+		shutdownTimeout: time.Duration(cfg.ShutdownTimeout),
+		drainDelay:      time.Duration(cfg.DrainDelay),
+	}
+	if cfg.TLS.CertFile != "" {
+		certs, err := newCertReloader(cfg.TLS.CertFile, cfg.TLS.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		s.certs = certs
+		s.http.TLSConfig = &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: certs.GetCertificate,
+		}
+		go certs.watch(time.Duration(cfg.TLS.ReloadInterval), s.stopped())
+	}
+	return s, nil
+}
+
+// stopped returns a channel closed once the server shuts down.
+func (s *Server) stopped() <-chan struct{} {
+	done := make(chan struct{})
+	s.http.RegisterOnShutdown(func() { close(done) })
+	return done
+}
+
+// OnDrain registers fn to be called once the server starts shutting down,
+// before the drain delay.
+func (s *Server) OnDrain(fn func()) {
+	s.onDrain = append(s.onDrain, fn)
+}
+
+// Run serves until ctx is done. It then calls the OnDrain functions and
+// keeps serving for the drain delay, letting the load balancer notice the
+// server is unready, before it stops accepting connections and waits up to
+// the shutdown timeout for the requests in flight, cutting off those still
+// running after it. It returns once every request is finished.
+func (s *Server) Run(ctx context.Context) error {
+	listener, err := net.Listen("tcp", s.http.Addr)
+	if err != nil {
+		return err
+	}
+	served := make(chan error, 1)
+	go func() {
+		slog.Info("listening", "addr", listener.Addr().String(), "tls", s.certs != nil)
+		if s.certs != nil {
+			served <- s.http.ServeTLS(listener, "", "")
+		} else {
+			served <- s.http.Serve(listener)
+		}
+	}()
+
+	select {
+	case err := <-served:
+		return err
+	case <-ctx.Done():
+	}
+	for _, fn := range s.onDrain {
+		fn()
+	}
+	if s.drainDelay > 0 {
+		slog.Info("draining, serving until the load balancer stops sending requests", "delay", s.drainDelay)
+		select {
+		case err := <-served:
+			return err
+		case <-time.After(s.drainDelay):
+		}
+	}
+	slog.Info("shutting down, waiting for the requests in flight", "timeout", s.shutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+	defer cancel()
+	err = s.http.Shutdown(shutdownCtx)
+	if errors.Is(err, context.DeadlineExceeded) {
+		slog.Warn("shutdown timed out, closing the remaining connections")
+		err = s.http.Close()
+	}
+	if served := <-served; !errors.Is(served, http.ErrServerClosed) {
+		return served
+	}
+	return err
+}
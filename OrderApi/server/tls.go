@@ -0,0 +1,99 @@
+package server
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// certReloader serves a TLS certificate loaded from disk, loading it again
+// whenever its files change, so a renewed certificate is picked up without a
+// restart.
+type certReloader struct {
+	certFile, keyFile string
+
+	mu   sync.RWMutex
+	cert *tls.Certificate
+	// modified is the latest modification time of the files last tried, so
+	// files failing to load are not tried again until they change.
+	modified time.Time
+}
+
+func newCertReloader(certFile, keyFile string) (*certReloader, error) {
+	r := &certReloader{certFile: certFile, keyFile: keyFile}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetCertificate returns the certificate last loaded, as tls.Config wants.
+func (r *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+// load loads the certificate when its files changed since the last try.
+func (r *certReloader) load() error {
+	modified, err := r.lastModified()
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cert != nil && !modified.After(r.modified) {
+		return nil
+	}
+	r.modified = modified
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+	r.cert = &cert
+	return nil
+}
+
+func (r *certReloader) lastModified() (time.Time, error) {
+	var latest time.Time
+	for _, name := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(name)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("tls: %w", err)
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+// watch reloads the certificate every interval until done is closed. A
+// certificate failing to load, such as one half written, is logged and the
+// previous one kept.
+func (r *certReloader) watch(interval time.Duration, done <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			before := r.loaded()
+			if err := r.load(); err != nil {
+				slog.Error("reloading the TLS certificate failed, keeping the current one", "err", err, "file", r.certFile)
+			} else if r.loaded() != before {
+				slog.Info("TLS certificate reloaded", "file", r.certFile)
+			}
+		}
+	}
+}
+
+func (r *certReloader) loaded() *tls.Certificate {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert
+}
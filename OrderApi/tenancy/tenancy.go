@@ -0,0 +1,128 @@
+// Package tenancy resolves the tenant, the business unit, of each order
+// request and makes it available to the handlers, which limit the request
+// to the orders of that tenant.
+package tenancy
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"assignment2.id/orderapi/apierror"
+	"assignment2.id/orderapi/auth"
+	"assignment2.id/orderapi/config"
+	"assignment2.id/orderapi/ratelimit"
+	"assignment2.id/orderapi/validation"
+	"github.com/gin-gonic/gin"
+)
+
+// tenantKey holds the tenant in the gin context.
+const tenantKey = "tenancy.tenant"
+
+// Where the tenant of a request is looked for.
+const (
+	// SourceClaim is the tenant of the API key or JWT of the principal.
+	SourceClaim     = "claim"
+	SourceHeader    = "header"
+	SourceSubdomain = "subdomain"
+)
+
+// Resolver finds the tenant of requests.
+type Resolver struct {
+	sources    []string
+	header     string
+	baseDomain string
+	// limit is the rate limit of each tenant, its buckets kept in limits.
+	limit  ratelimit.Limit
+	limits ratelimit.Store
+}
+
+// New returns the resolver cfg configures, keeping the rate limit buckets of
+// the tenants in limits.
+func New(cfg config.TenancyConfig, limits ratelimit.Store) *Resolver {
+	return &Resolver{
+		sources:    cfg.Sources,
+		header:     cfg.Header,
+		baseDomain: strings.ToLower(strings.TrimPrefix(cfg.BaseDomain, ".")),
+		limit:      ratelimit.Limit{Rate: cfg.Rate, Burst: cfg.Burst},
+		limits:     limits,
+	}
+}
+
+// Resolve returns the tenant of the request from the first of the sources
+// naming one, or "" when none does.
+func (r *Resolver) Resolve(ctx *gin.Context) string {
+	for _, tenant := range r.named(ctx) {
+		if tenant != "" {
+			return tenant
+		}
+	}
+	return ""
+}
+
+// named returns the tenant each source names for the request, "" for none.
+func (r *Resolver) named(ctx *gin.Context) []string {
+	tenants := make([]string, len(r.sources))
+	for i, source := range r.sources {
+		switch source {
+		case SourceClaim:
+			if principal := auth.PrincipalFrom(ctx); principal != nil {
+				tenants[i] = principal.Tenant
+			}
+		case SourceHeader:
+			tenants[i] = strings.TrimSpace(ctx.GetHeader(r.header))
+		case SourceSubdomain:
+			tenants[i] = r.subdomain(ctx.Request.Host)
+		}
+	}
+	return tenants
+}
+
+// subdomain returns the label host adds to the base domain, "" when host is
+// not a direct subdomain of it.
+func (r *Resolver) subdomain(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	label := strings.TrimSuffix(strings.ToLower(host), "."+r.baseDomain)
+	if label == host || strings.Contains(label, ".") {
+		return ""
+	}
+	return label
+}
+
+// Middleware aborts with 400 the requests resolving to no valid tenant, with
+// 403 those naming a tenant other than the one their principal is limited to
+// and with 429 those beyond the rate of their tenant. It stores the tenant of
+// the others for From.
+func (r *Resolver) Middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if principal := auth.PrincipalFrom(ctx); principal != nil && principal.Tenant != "" {
+			for _, named := range r.named(ctx) {
+				if named != "" && named != principal.Tenant {
+					apierror.Abort(ctx, apierror.New(http.StatusForbidden, apierror.CodeTenantForbidden, named))
+					return
+				}
+			}
+		}
+		tenant := r.Resolve(ctx)
+		if tenant == "" {
+			apierror.Abort(ctx, apierror.New(http.StatusBadRequest, apierror.CodeTenantRequired, r.header).WithField(r.header))
+			return
+		}
+		if !validation.TenantID(tenant) {
+			apierror.Abort(ctx, apierror.New(http.StatusBadRequest, apierror.CodeInvalidTenant, tenant))
+			return
+		}
+		if !ratelimit.Check(ctx, r.limits, "tenant", r.limit, tenant) {
+			return
+		}
+		ctx.Set(tenantKey, tenant)
+		ctx.Next()
+	}
+}
+
+// From returns the tenant of the request, "" when tenancy is disabled.
+func From(ctx *gin.Context) string {
+	return ctx.GetString(tenantKey)
+}
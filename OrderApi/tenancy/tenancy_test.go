@@ -0,0 +1,122 @@
+package tenancy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"assignment2.id/orderapi/apierror"
+	"assignment2.id/orderapi/auth"
+	"assignment2.id/orderapi/config"
+	"assignment2.id/orderapi/database"
+	"assignment2.id/orderapi/ratelimit"
+	"github.com/gin-gonic/gin"
+)
+
+// newTestRouter answers GET / with the tenant of the request, for the API
+// keys "global", reaching every tenant, and "acme", limited to acme.
+func newTestRouter(t *testing.T, sources ...string) *gin.Engine {
+	t.Helper()
+	keys := database.NewMemoryStore()
+	for name, tenant := range map[string]string{"global": "", "acme": "acme"} {
+		key := database.APIKey{Name: name, Hash: auth.HashAPIKey(name), Roles: auth.RoleUser, TenantID: tenant}
+		if err := keys.CreateAPIKey(&key); err != nil {
+			t.Fatal(err)
+		}
+	}
+	resolver := New(config.TenancyConfig{
+		Enabled:    true,
+		Sources:    sources,
+		Header:     "X-Tenant-ID",
+		BaseDomain: ".orders.example.com",
+	}, ratelimit.NewMemoryStore())
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	authenticator := &auth.Authenticator{Keys: keys}
+	router.Use(apierror.Middleware(func(error) *apierror.Error { return nil }), authenticator.Middleware(), resolver.Middleware())
+	router.GET("/", func(ctx *gin.Context) {
+		ctx.String(http.StatusOK, From(ctx))
+	})
+	return router
+}
+
+func TestMiddleware(t *testing.T) {
+	all := []string{SourceClaim, SourceHeader, SourceSubdomain}
+	tests := []struct {
+		name    string
+		sources []string
+		key     string
+		header  string
+		host    string
+		status  int
+		tenant  string
+		code    apierror.Code
+	}{
+		{"claim", all, "acme", "", "", http.StatusOK, "acme", ""},
+		{"header", all, "global", "beta", "", http.StatusOK, "beta", ""},
+		{"subdomain", all, "global", "", "gamma.orders.example.com", http.StatusOK, "gamma", ""},
+		{"subdomain with port", all, "global", "", "Gamma.orders.example.com:8080", http.StatusOK, "gamma", ""},
+		{"first source wins", all, "global", "beta", "gamma.orders.example.com", http.StatusOK, "beta", ""},
+		{"header matching the claim", all, "acme", "acme", "", http.StatusOK, "acme", ""},
+		{"header only ignores the claim", []string{SourceHeader}, "acme", "", "", http.StatusBadRequest, "", apierror.CodeTenantRequired},
+		{"header of another tenant", all, "acme", "beta", "", http.StatusForbidden, "", apierror.CodeTenantForbidden},
+		{"subdomain of another tenant", all, "acme", "", "beta.orders.example.com", http.StatusForbidden, "", apierror.CodeTenantForbidden},
+		{"another tenant from a later source", []string{SourceHeader, SourceClaim}, "acme", "beta", "", http.StatusForbidden, "", apierror.CodeTenantForbidden},
+		{"no tenant", all, "global", "", "orders.example.com", http.StatusBadRequest, "", apierror.CodeTenantRequired},
+		{"nested subdomain", all, "global", "", "a.b.orders.example.com", http.StatusBadRequest, "", apierror.CodeTenantRequired},
+		{"other domain", all, "global", "", "gamma.example.org", http.StatusBadRequest, "", apierror.CodeTenantRequired},
+		{"invalid tenant", all, "global", "Not_A_Tenant", "", http.StatusBadRequest, "", apierror.CodeInvalidTenant},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(auth.APIKeyHeader, tt.key)
+			if tt.header != "" {
+				req.Header.Set("X-Tenant-ID", tt.header)
+			}
+			if tt.host != "" {
+				req.Host = tt.host
+			}
+			w := httptest.NewRecorder()
+			newTestRouter(t, tt.sources...).ServeHTTP(w, req)
+			if w.Code != tt.status {
+				t.Fatalf("got status %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			if tt.status == http.StatusOK {
+				if w.Body.String() != tt.tenant {
+					t.Errorf("got tenant %q, want %q", w.Body, tt.tenant)
+				}
+				return
+			}
+			var problem apierror.Problem
+			if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+				t.Fatal(err)
+			}
+			if problem.Code != tt.code {
+				t.Errorf("got code %q, want %q", problem.Code, tt.code)
+			}
+		})
+	}
+}
+
+func TestMiddlewareRateLimitsTenants(t *testing.T) {
+	resolver := New(config.TenancyConfig{Sources: []string{SourceHeader}, Header: "X-Tenant-ID", Rate: 1, Burst: 1}, ratelimit.NewMemoryStore())
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(apierror.Middleware(func(error) *apierror.Error { return nil }), resolver.Middleware())
+	router.GET("/", func(ctx *gin.Context) {})
+	for i, tt := range []struct {
+		tenant string
+		status int
+	}{{"acme", http.StatusOK}, {"acme", http.StatusTooManyRequests}, {"beta", http.StatusOK}} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Tenant-ID", tt.tenant)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != tt.status {
+			t.Errorf("request %d of %s: got status %d, want %d", i+1, tt.tenant, w.Code, tt.status)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package tracing
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// spanKey holds, in a GORM statement, the span of its query.
+const spanKey = "tracing:span"
+
+// dbSystems are the db.system of the GORM dialectors.
+var dbSystems = map[string]attribute.KeyValue{
+	"postgres": semconv.DBSystemPostgreSQL,
+	"sqlite":   semconv.DBSystemSqlite,
+}
+
+// Instrument records a span of each query db runs, under the span of its
+// context, with the SQL statement. The statement keeps its placeholders, the
+// values bound to them are left out.
+func Instrument(db *gorm.DB) error {
+	return db.Use(gormPlugin{})
+}
+
+type gormPlugin struct{}
+
+func (gormPlugin) Name() string {
+	return "tracing"
+}
+
+// registerer is a GORM callback waiting for its function.
+type registerer interface {
+	Register(name string, fn func(*gorm.DB)) error
+}
+
+func (gormPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	operations := []struct {
+		name          string
+		before, after registerer
+	}{
+		{"create", cb.Create().Before("gorm:create"), cb.Create().After("gorm:create")},
+		{"query", cb.Query().Before("gorm:query"), cb.Query().After("gorm:query")},
+		{"update", cb.Update().Before("gorm:update"), cb.Update().After("gorm:update")},
+		{"delete", cb.Delete().Before("gorm:delete"), cb.Delete().After("gorm:delete")},
+		{"row", cb.Row().Before("gorm:row"), cb.Row().After("gorm:row")},
+		{"raw", cb.Raw().Before("gorm:raw"), cb.Raw().After("gorm:raw")},
+	}
+	for _, op := range operations {
+		if err := op.before.Register("tracing:before_"+op.name, startQuery(op.name)); err != nil {
+			return err
+		}
+		if err := op.after.Register("tracing:after_"+op.name, endQuery); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// startQuery returns the callback starting the span of the queries of
+// operation.
+func startQuery(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		name := operation
+		if db.Statement.Table != "" {
+			name += " " + db.Statement.Table
+		}
+		attrs := []attribute.KeyValue{semconv.DBOperation(operation), semconv.DBSQLTable(db.Statement.Table)}
+		if system, ok := dbSystems[db.Dialector.Name()]; ok {
+			attrs = append(attrs, system)
+		}
+		_, span := tracer().Start(db.Statement.Context, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+		db.InstanceSet(spanKey, span)
+	}
+}
+
+// endQuery ends the span of the query with its statement, known once it ran.
+func endQuery(db *gorm.DB) {
+	value, ok := db.InstanceGet(spanKey)
+	if !ok {
+		return
+	}
+	span := value.(trace.Span)
+	span.SetAttributes(
+		semconv.DBStatement(db.Statement.SQL.String()),
+		attribute.Int64("db.rows_affected", db.RowsAffected),
+	)
+	if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		span.RecordError(db.Error)
+		span.SetStatus(codes.Error, db.Error.Error())
+	}
+	span.End()
+}
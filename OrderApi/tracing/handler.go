@@ -0,0 +1,35 @@
+package tracing
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler records a span of the handler of each request, named after it,
+// such as "v1.OrderController.CreateOrder", under the span of Middleware.
+// It goes last, right before the handlers of a controller. The errors the
+// handler aborts with are recorded on the span, their response is written
+// after it ends.
+func Handler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		spanCtx, span := tracer().Start(ctx.Request.Context(), handlerName(ctx.HandlerName()))
+		defer span.End()
+		ctx.Request = ctx.Request.WithContext(spanCtx)
+
+		ctx.Next()
+
+		for _, err := range ctx.Errors {
+			span.RecordError(err.Err)
+		}
+	}
+}
+
+// handlerName shortens the name of a handler method value, such as
+// "assignment2.id/orderapi/controllers/v1.(*OrderController).CreateOrder-fm",
+// to "v1.OrderController.CreateOrder".
+func handlerName(name string) string {
+	name = name[strings.LastIndex(name, "/")+1:]
+	name = strings.TrimSuffix(name, "-fm")
+	return strings.NewReplacer("(*", "", ")", "").Replace(name)
+}
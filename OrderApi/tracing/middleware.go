@@ -0,0 +1,51 @@
+package tracing
+
+import (
+	"net/http"
+
+	"assignment2.id/orderapi/logging"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Middleware records a server span of each request, continuing the trace of
+// its traceparent header, and adds the trace ID to the logger of the request.
+// It goes after logging.Middleware, whose logger it extends.
+func Middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		parent := otel.GetTextMapPropagator().Extract(ctx.Request.Context(), propagation.HeaderCarrier(ctx.Request.Header))
+		route := ctx.FullPath()
+		name := ctx.Request.Method + " " + route
+		if route == "" {
+			name = ctx.Request.Method + " unmatched"
+		}
+		// The path and query string are left out, they may hold customer
+		// names.
+		spanCtx, span := tracer().Start(parent, name,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPMethod(ctx.Request.Method),
+				semconv.HTTPRoute(route),
+				semconv.HTTPClientIP(ctx.ClientIP()),
+			),
+		)
+		defer span.End()
+		if span.SpanContext().IsValid() {
+			logger := logging.FromContext(spanCtx).With("trace_id", span.SpanContext().TraceID().String())
+			spanCtx = logging.NewContext(spanCtx, logger)
+		}
+		ctx.Request = ctx.Request.WithContext(spanCtx)
+
+		ctx.Next()
+
+		status := ctx.Writer.Status()
+		span.SetAttributes(semconv.HTTPStatusCode(status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"assignment2.id/orderapi/database"
+	"assignment2.id/orderapi/models"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Attributes of the OrderStore spans.
+const (
+	orderIDKey  = attribute.Key("order.id")
+	itemIDKey   = attribute.Key("item.id")
+	apiKeyIDKey = attribute.Key("api_key.id")
+)
+
+// Store returns store recording a span of each call, under the span of the
+// context given to WithContext. The queries the call runs are recorded under
+// it by Instrument.
+func Store(store database.OrderStore) database.OrderStore {
+	return &tracedStore{OrderStore: store, ctx: context.Background()}
+}
+
+type tracedStore struct {
+	database.OrderStore
+	ctx context.Context
+}
+
+func (s *tracedStore) Scoped(scope database.Scope) database.OrderStore {
+	return &tracedStore{OrderStore: s.OrderStore.Scoped(scope), ctx: s.ctx}
+}
+
+func (s *tracedStore) WithContext(ctx context.Context) database.OrderStore {
+	return &tracedStore{OrderStore: s.OrderStore.WithContext(ctx), ctx: ctx}
+}
+
+// start starts the span of the call name and returns the store running in it.
+func (s *tracedStore) start(name string, attrs ...attribute.KeyValue) (database.OrderStore, trace.Span) {
+	ctx, span := tracer().Start(s.ctx, "OrderStore."+name, trace.WithAttributes(attrs...))
+	return s.OrderStore.WithContext(ctx), span
+}
+
+// end ends span, recording err. Missing records are not failures of the
+// store.
+func end(span trace.Span, err error) {
+	if err != nil && !missing(err) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func (s *tracedStore) CreateOrder(order *models.Order) error {
+	store, span := s.start("CreateOrder")
+	err := store.CreateOrder(order)
+	span.SetAttributes(orderIDKey.Int64(int64(order.ID)))
+	end(span, err)
+	return err
+}
+
+func (s *tracedStore) GetOrderById(id uint) (models.Order, error) {
+	store, span := s.start("GetOrderById", orderIDKey.Int64(int64(id)))
+	order, err := store.GetOrderById(id)
+	end(span, err)
+	return order, err
+}
+
+func (s *tracedStore) GetOrderByIds(ids ...uint) ([]models.Order, []uint, error) {
+	store, span := s.start("GetOrderByIds", attribute.Int("order.ids", len(ids)))
+	orders, missing, err := store.GetOrderByIds(ids...)
+	end(span, err)
+	return orders, missing, err
+}
+
+func (s *tracedStore) ListOrders(q database.OrderListQuery) (database.OrderPage, error) {
+	store, span := s.start("ListOrders")
+	page, err := store.ListOrders(q)
+	end(span, err)
+	return page, err
+}
+
+func (s *tracedStore) UpdateOrderById(id uint, argOrder *models.Order, version uint) error {
+	store, span := s.start("UpdateOrderById", orderIDKey.Int64(int64(id)))
+	err := store.UpdateOrderById(id, argOrder, version)
+	end(span, err)
+	return err
+}
+
+func (s *tracedStore) PatchOrderById(id uint, version uint, patch func(order *models.Order) error) (models.Order, error) {
+	store, span := s.start("PatchOrderById", orderIDKey.Int64(int64(id)))
+	order, err := store.PatchOrderById(id, version, patch)
+	end(span, err)
+	return order, err
+}
+
+func (s *tracedStore) GetItems(orderID uint) ([]models.Item, error) {
+	store, span := s.start("GetItems", orderIDKey.Int64(int64(orderID)))
+	items, err := store.GetItems(orderID)
+	end(span, err)
+	return items, err
+}
+
+func (s *tracedStore) GetItem(orderID, itemID uint) (models.Item, error) {
+	store, span := s.start("GetItem", orderIDKey.Int64(int64(orderID)), itemIDKey.Int64(int64(itemID)))
+	item, err := store.GetItem(orderID, itemID)
+	end(span, err)
+	return item, err
+}
+
+func (s *tracedStore) CreateItem(orderID uint, item *models.Item, version uint) (uint, error) {
+	store, span := s.start("CreateItem", orderIDKey.Int64(int64(orderID)))
+	newVersion, err := store.CreateItem(orderID, item, version)
+	span.SetAttributes(itemIDKey.Int64(int64(item.ID)))
+	end(span, err)
+	return newVersion, err
+}
+
+func (s *tracedStore) UpdateItem(orderID, itemID, version uint, update func(item *models.Item) error) (models.Item, uint, error) {
+	store, span := s.start("UpdateItem", orderIDKey.Int64(int64(orderID)), itemIDKey.Int64(int64(itemID)))
+	item, newVersion, err := store.UpdateItem(orderID, itemID, version, update)
+	end(span, err)
+	return item, newVersion, err
+}
+
+func (s *tracedStore) DeleteItem(orderID, itemID, version uint) (uint, error) {
+	store, span := s.start("DeleteItem", orderIDKey.Int64(int64(orderID)), itemIDKey.Int64(int64(itemID)))
+	newVersion, err := store.DeleteItem(orderID, itemID, version)
+	end(span, err)
+	return newVersion, err
+}
+
+func (s *tracedStore) DeleteOrderById(id uint, version uint) error {
+	store, span := s.start("DeleteOrderById", orderIDKey.Int64(int64(id)))
+	err := store.DeleteOrderById(id, version)
+	end(span, err)
+	return err
+}
+
+func (s *tracedStore) RestoreOrderById(id uint) error {
+	store, span := s.start("RestoreOrderById", orderIDKey.Int64(int64(id)))
+	err := store.RestoreOrderById(id)
+	end(span, err)
+	return err
+}
+
+func (s *tracedStore) PurgeOrderById(id uint, version uint) error {
+	store, span := s.start("PurgeOrderById", orderIDKey.Int64(int64(id)))
+	err := store.PurgeOrderById(id, version)
+	end(span, err)
+	return err
+}
+
+func (s *tracedStore) PurgeDeletedBefore(t time.Time) (int64, error) {
+	store, span := s.start("PurgeDeletedBefore")
+	purged, err := store.PurgeDeletedBefore(t)
+	span.SetAttributes(attribute.Int64("orders.purged", purged))
+	end(span, err)
+	return purged, err
+}
+
+func (s *tracedStore) GetIdempotencyKey(key string, since time.Time) (database.IdempotencyKey, error) {
+	store, span := s.start("GetIdempotencyKey")
+	record, err := store.GetIdempotencyKey(key, since)
+	end(span, err)
+	return record, err
+}
+
+func (s *tracedStore) CreateOrderOnce(order *models.Order, record *database.IdempotencyKey, since time.Time, respond func(order *models.Order) ([]byte, error)) error {
+	store, span := s.start("CreateOrderOnce")
+	err := store.CreateOrderOnce(order, record, since, respond)
+	span.SetAttributes(orderIDKey.Int64(int64(order.ID)))
+	end(span, err)
+	return err
+}
+
+func (s *tracedStore) PurgeIdempotencyKeysBefore(t time.Time) (int64, error) {
+	store, span := s.start("PurgeIdempotencyKeysBefore")
+	purged, err := store.PurgeIdempotencyKeysBefore(t)
+	span.SetAttributes(attribute.Int64("idempotency_keys.purged", purged))
+	end(span, err)
+	return purged, err
+}
+
+func (s *tracedStore) CreateAPIKey(key *database.APIKey) error {
+	store, span := s.start("CreateAPIKey")
+	err := store.CreateAPIKey(key)
+	span.SetAttributes(apiKeyIDKey.Int64(int64(key.ID)))
+	end(span, err)
+	return err
+}
+
+func (s *tracedStore) GetAPIKeyByHash(hash string) (database.APIKey, error) {
+	store, span := s.start("GetAPIKeyByHash")
+	key, err := store.GetAPIKeyByHash(hash)
+	end(span, err)
+	return key, err
+}
+
+func (s *tracedStore) ListAPIKeys() ([]database.APIKey, error) {
+	store, span := s.start("ListAPIKeys")
+	keys, err := store.ListAPIKeys()
+	end(span, err)
+	return keys, err
+}
+
+func (s *tracedStore) RevokeAPIKey(id uint) (database.APIKey, error) {
+	store, span := s.start("RevokeAPIKey", apiKeyIDKey.Int64(int64(id)))
+	key, err := store.RevokeAPIKey(id)
+	end(span, err)
+	return key, err
+}
+
+func missing(err error) bool {
+	return errors.Is(err, database.ErrRecordNotFound) || errors.Is(err, database.ErrItemNotFound) || errors.Is(err, database.ErrAPIKeyNotFound)
+}
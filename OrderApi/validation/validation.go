@@ -0,0 +1,182 @@
+// Package validation checks orders, items and the other request bodies
+// against the rules declared in their validate tags, reporting every
+// violation at once.
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"assignment2.id/orderapi/models"
+	"github.com/go-playground/validator/v10"
+)
+
+// MaxOrderedAtAhead is how far in the future OrderedAt may be, to allow for
+// clock skew between clients and the server.
+const MaxOrderedAtAhead = 24 * time.Hour
+
+// The rules a Violation reports.
+const (
+	RuleRequired      = "required"
+	RuleTooLong       = "too_long"
+	RuleTooMany       = "too_many"
+	RuleNotPositive   = "not_positive"
+	RuleInvalidFormat = "invalid_format"
+	RuleTooFarAhead   = "too_far_ahead"
+	RuleNotAllowed    = "not_allowed"
+)
+
+// Violation is a value breaking a rule.
+type Violation struct {
+	// Field is a JSON pointer to the value, such as "/Items/0/Quantity".
+	Field string
+	Rule  string
+	// Param is the limit of the rule, such as "8192" for too_long or the
+	// hours of MaxOrderedAtAhead for too_far_ahead.
+	Param string
+}
+
+// Errors lists every violation found in a value.
+type Errors []Violation
+
+func (e Errors) Error() string {
+	parts := make([]string, len(e))
+	for i, v := range e {
+		parts[i] = fmt.Sprintf("%s: %s %s", v.Field, v.Rule, v.Param)
+	}
+	return "validation: " + strings.Join(parts, "; ")
+}
+
+var itemCodeFormat = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// tenantFormat is a DNS label, so a tenant can also be a subdomain.
+var tenantFormat = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// TenantID reports whether id is a valid tenant: at most 63 lowercase
+// letters, digits and inner hyphens.
+func TenantID(id string) bool {
+	return tenantFormat.MatchString(id)
+}
+
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New()
+	v.RegisterValidation("itemcode", func(fl validator.FieldLevel) bool {
+		return itemCodeFormat.MatchString(fl.Field().String())
+	})
+	v.RegisterValidation("notfarahead", func(fl validator.FieldLevel) bool {
+		t, ok := fl.Field().Interface().(time.Time)
+		return !ok || !t.After(time.Now().Add(MaxOrderedAtAhead))
+	})
+	return v
+}
+
+// Order validates order. With partial set, as for a PUT keeping the fields
+// it leaves empty, a missing CustomerName is not a violation.
+func Order(order *models.Order, partial bool) error {
+	return orderExcept(order, func(string) bool { return partial })
+}
+
+// PatchedOrder validates an order a patch produced. The top-level fields in
+// cleared, such as "/CustomerName", were removed by the patch, or set to
+// null, to empty them, so they are not required.
+func PatchedOrder(order *models.Order, cleared []string) error {
+	return orderExcept(order, func(field string) bool {
+		for _, c := range cleared {
+			if c == field {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// orderExcept validates order, leaving out the required rule of the
+// top-level fields optional reports.
+func orderExcept(order *models.Order, optional func(field string) bool) error {
+	violations := check(order)
+	kept := violations[:0]
+	for _, v := range violations {
+		if v.Rule != RuleRequired || strings.Count(v.Field, "/") != 1 || !optional(v.Field) {
+			kept = append(kept, v)
+		}
+	}
+	if len(kept) == 0 {
+		return nil
+	}
+	return kept
+}
+
+// Item validates item.
+func Item(item *models.Item) error {
+	return Struct(item)
+}
+
+// Struct validates v, a pointer to any struct with validate tags.
+func Struct(v interface{}) error {
+	if violations := check(v); len(violations) > 0 {
+		return violations
+	}
+	return nil
+}
+
+func check(v interface{}) Errors {
+	err := validate.Struct(v)
+	var fieldErrs validator.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		return nil
+	}
+	violations := make(Errors, 0, len(fieldErrs))
+	for _, fe := range fieldErrs {
+		violation := Violation{
+			Field: pointer(fe.Namespace()),
+			Rule:  rule(fe),
+			Param: fe.Param(),
+		}
+		switch violation.Rule {
+		case RuleTooFarAhead:
+			violation.Param = strconv.Itoa(int(MaxOrderedAtAhead / time.Hour))
+		case RuleNotAllowed:
+			violation.Param = strings.ReplaceAll(violation.Param, " ", ", ")
+		}
+		violations = append(violations, violation)
+	}
+	return violations
+}
+
+// pointer turns a validator namespace such as "Order.Items[0].Quantity" into
+// the JSON pointer "/Items/0/Quantity".
+func pointer(namespace string) string {
+	if i := strings.IndexByte(namespace, '.'); i >= 0 {
+		namespace = namespace[i+1:]
+	} else {
+		return ""
+	}
+	namespace = strings.NewReplacer("[", ".", "]", "").Replace(namespace)
+	return "/" + strings.ReplaceAll(namespace, ".", "/")
+}
+
+func rule(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "max":
+		if fe.Kind() == reflect.Slice {
+			return RuleTooMany
+		}
+		return RuleTooLong
+	case "gt":
+		return RuleNotPositive
+	case "itemcode":
+		return RuleInvalidFormat
+	case "notfarahead":
+		return RuleTooFarAhead
+	case "oneof":
+		return RuleNotAllowed
+	}
+	return fe.Tag()
+}
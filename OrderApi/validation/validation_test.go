@@ -0,0 +1,85 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"assignment2.id/orderapi/models"
+)
+
+// violations returns the violations of err as "field rule" strings.
+func violations(t *testing.T, err error) []string {
+	t.Helper()
+	if err == nil {
+		return nil
+	}
+	var errs Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("got error %v, want Errors", err)
+	}
+	var got []string
+	for _, v := range errs {
+		got = append(got, v.Field+" "+v.Rule)
+	}
+	return got
+}
+
+func TestOrder(t *testing.T) {
+	items := func(quantities ...uint) []models.Item {
+		var items []models.Item
+		for i, q := range quantities {
+			items = append(items, models.Item{ItemCode: fmt.Sprintf("X%d", i), Quantity: q})
+		}
+		return items
+	}
+	tests := []struct {
+		name    string
+		order   models.Order
+		partial bool
+		want    []string
+	}{
+		{"valid", models.Order{CustomerName: "A", Items: items(1)}, false, nil},
+		{"missing name", models.Order{Items: items(1)}, false, []string{"/CustomerName required"}},
+		{"partial without name", models.Order{Items: items(1)}, true, nil},
+		{"partial still checks items", models.Order{Items: items(1, 0)}, true, []string{"/Items/1/Quantity not_positive"}},
+		{"partial still checks the name length", models.Order{CustomerName: strings.Repeat("a", 8193)}, true, []string{"/CustomerName too_long"}},
+		{"every violation", models.Order{Items: []models.Item{{ItemCode: "-x", Quantity: 0}}}, false, []string{
+			"/CustomerName required", "/Items/0/ItemCode invalid_format", "/Items/0/Quantity not_positive",
+		}},
+		{"item code still required when partial", models.Order{Items: []models.Item{{Quantity: 1}}}, true, []string{"/Items/0/ItemCode required"}},
+		{"too many items", models.Order{CustomerName: "A", Items: make([]models.Item, 101)}, true, []string{"/Items too_many"}},
+		{"ordered far ahead", models.Order{CustomerName: "A", OrderedAt: time.Now().Add(MaxOrderedAtAhead + time.Hour)}, false, []string{"/OrderedAt too_far_ahead"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := violations(t, Order(&tt.order, tt.partial))
+			if strings.Join(got, ", ") != strings.Join(tt.want, ", ") {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPatchedOrder(t *testing.T) {
+	order := models.Order{Items: []models.Item{{Quantity: 1}}}
+	tests := []struct {
+		name    string
+		cleared []string
+		want    []string
+	}{
+		{"nothing cleared", nil, []string{"/CustomerName required", "/Items/0/ItemCode required"}},
+		{"name cleared", []string{"/CustomerName"}, []string{"/Items/0/ItemCode required"}},
+		{"items cleared keeps nested rules", []string{"/Items"}, []string{"/CustomerName required", "/Items/0/ItemCode required"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := violations(t, PatchedOrder(&order, tt.cleared))
+			if strings.Join(got, ", ") != strings.Join(tt.want, ", ") {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}